@@ -0,0 +1,209 @@
+// Package adapters contém o núcleo compartilhado pelos adaptadores de frameworks HTTP
+// (gin, echo, fiber e chi). Cada adaptador extrai a requisição do contexto do seu
+// framework e usa Evaluate e Acquire para aplicar o mesmo RateLimiter e as mesmas regras.
+//
+// Os adaptadores aplicam os limites por cliente, as regras por rota, as cotas, os limites
+// globais e o limite de concorrência. Rotas com regra (WithRouteRule) usam apenas a regra,
+// sem os limites globais, as cotas e o descarte por prioridade. O limite de banda
+// (WithBandwidthLimit) depende de envolver os corpos da requisição e da resposta e só é
+// aplicado pelo middleware net/http (pacote middleware).
+package adapters
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// DefaultTokenHeader é o cabeçalho padrão de onde o token de acesso é lido
+const DefaultTokenHeader = "API_KEY"
+
+// Config reúne as opções comuns a todos os adaptadores
+type Config struct {
+	// Cabeçalho de onde o token de acesso é lido
+	TokenHeader string
+	// Regras nomeadas por padrão de rota (por exemplo, "/users/:id")
+	RouteRules map[string]string
+	// Parâmetros de rota incluídos nos descritores das regras
+	Params []string
+	// Custo das requisições por padrão de rota (rotas sem custo custam um)
+	RouteCosts map[string]int
+	// Prioridade das requisições nos limites globais por padrão de rota
+	RoutePriorities map[string]ratelimiter.Priority
+}
+
+// Option configura parâmetros opcionais dos adaptadores
+type Option func(*Config)
+
+// WithTokenHeader define o cabeçalho de onde o token de acesso é lido
+func WithTokenHeader(header string) Option {
+	return func(c *Config) {
+		c.TokenHeader = header
+	}
+}
+
+// WithRouteRule aplica uma regra nomeada às requisições de um padrão de rota, na sintaxe
+// do framework ("/users/:id" no gin, echo e fiber, "/users/{id}" no chi). Rotas sem regra
+// usam os limites por IP e token de RateLimiter.Allow. A regra é avaliada por
+// RateLimiter.Check e substitui todos os demais limites: as requisições da rota não
+// consomem os limites globais nem as cotas e não são descartadas por prioridade.
+func WithRouteRule(pattern, rule string) Option {
+	return func(c *Config) {
+		c.RouteRules[pattern] = rule
+	}
+}
+
+// WithParams inclui parâmetros de rota nos descritores das regras, dando a cada valor
+// (por exemplo, cada tenant) seu próprio contador
+func WithParams(names ...string) Option {
+	return func(c *Config) {
+		c.Params = append(c.Params, names...)
+	}
+}
+
+// WithRouteCost define quanto as requisições de um padrão de rota consomem dos limites,
+// na sintaxe do framework
+func WithRouteCost(pattern string, cost int) Option {
+	return func(c *Config) {
+		c.RouteCosts[pattern] = cost
+	}
+}
+
+// WithRoutePriority define a prioridade das requisições de um padrão de rota nos limites
+// globais, na sintaxe do framework. Rotas sem prioridade usam PriorityNormal com token e
+// PriorityLow sem token.
+func WithRoutePriority(pattern string, priority ratelimiter.Priority) Option {
+	return func(c *Config) {
+		c.RoutePriorities[pattern] = priority
+	}
+}
+
+// NewConfig cria a configuração com os valores padrão e aplica as opções
+func NewConfig(opts ...Option) *Config {
+	c := &Config{
+		TokenHeader:     DefaultTokenHeader,
+		RouteRules:      make(map[string]string),
+		RouteCosts:      make(map[string]int),
+		RoutePriorities: make(map[string]ratelimiter.Priority),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Request é a requisição extraída do contexto do framework
+type Request struct {
+	IP    string
+	Token string
+	// Caminho da requisição, usado para selecionar os limites globais
+	Path string
+	// Padrão da rota correspondente (vazio se nenhuma rota correspondeu)
+	Route string
+	// Função que retorna o valor de um parâmetro de rota
+	Param func(name string) string
+}
+
+// Evaluate aplica o rate limiter à requisição: a regra da rota, se houver, ou os limites
+// por IP e token, os limites globais e as cotas, com o custo e a prioridade da rota. A
+// regra da rota é avaliada sozinha, com RateLimiter.Check, sem limites globais, cotas ou
+// descarte por prioridade. Quando a requisição é negada, retorna a decisão e um
+// *LimitExceededError, um *GlobalLimitExceededError ou, se uma cota de longo prazo foi
+// excedida, um *QuotaExceededError.
+func Evaluate(ctx context.Context, limiter *ratelimiter.RateLimiter, cfg *Config, req Request) (*ratelimiter.Decision, error) {
+	cost := cfg.RouteCosts[req.Route]
+	rule, ok := cfg.RouteRules[req.Route]
+	if !ok {
+		return limiter.Decide(ctx, &ratelimiter.LimiterRequest{
+			IP:       req.IP,
+			Token:    req.Token,
+			Cost:     cost,
+			Path:     req.Path,
+			Priority: cfg.RoutePriorities[req.Route],
+		})
+	}
+
+	descriptors := map[string]string{"route": req.Route}
+	if req.Token != "" {
+		descriptors["token"] = req.Token
+	} else {
+		descriptors["ip"] = req.IP
+	}
+	for _, name := range cfg.Params {
+		if req.Param != nil {
+			descriptors["param."+name] = req.Param(name)
+		}
+	}
+
+	// Check não aplica limites globais, cotas nem descarte por prioridade
+	decision, err := limiter.Check(ctx, &ratelimiter.CheckRequest{
+		Rule:        rule,
+		Descriptors: descriptors,
+		Cost:        cost,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Allowed {
+		limitType := ratelimiter.IPLimit
+		if req.Token != "" {
+			limitType = ratelimiter.TokenLimit
+		}
+		limitErr := ratelimiter.NewLimitExceededError(limitType)
+		limitErr.RetryAfter = decision.Reset
+		return decision, limitErr
+	}
+
+	return decision, nil
+}
+
+// Acquire reserva uma vaga de concorrência para a requisição (veja RateLimiter.Acquire).
+// Chame antes de Evaluate, para que uma requisição sem vaga não consuma os limites. A
+// função retornada libera a vaga e deve ser chamada quando o handler terminar; sem
+// limite de concorrência, é uma função vazia. Quando não há vaga, retorna um
+// *ConcurrencyExceededError (veja ConcurrencyHeaders).
+func Acquire(ctx context.Context, limiter *ratelimiter.RateLimiter, req Request) (func(), error) {
+	return limiter.Acquire(ctx, &ratelimiter.LimiterRequest{IP: req.IP, Token: req.Token})
+}
+
+// IsLimitExceeded indica se o erro de Evaluate ou Acquire é uma negação por limite, cota
+// ou concorrência excedidos
+func IsLimitExceeded(err error) bool {
+	return ratelimiter.IsRejected(err)
+}
+
+// ConcurrencyHeaders retorna o Retry-After de uma negação por concorrência de Acquire, ou
+// nil para outros erros
+func ConcurrencyHeaders(err error) map[string]string {
+	var concurrencyErr *ratelimiter.ConcurrencyExceededError
+	if !errors.As(err, &concurrencyErr) {
+		return nil
+	}
+	return map[string]string{
+		"Retry-After": strconv.FormatInt(int64(math.Ceil(concurrencyErr.RetryAfter.Seconds())), 10),
+	}
+}
+
+// Headers retorna os cabeçalhos de rate limit da decisão, incluindo Retry-After quando
+// a requisição foi negada
+func Headers(decision *ratelimiter.Decision) map[string]string {
+	reset := strconv.FormatInt(int64(math.Ceil(decision.Reset.Seconds())), 10)
+
+	headers := map[string]string{
+		"X-RateLimit-Limit":     strconv.Itoa(decision.Limit),
+		"X-RateLimit-Remaining": strconv.Itoa(decision.Remaining),
+		"X-RateLimit-Reset":     reset,
+	}
+	if !decision.Allowed {
+		headers["Retry-After"] = reset
+	}
+	for name, value := range ratelimiter.QuotaHeaders(decision) {
+		headers[name] = value
+	}
+
+	return headers
+}
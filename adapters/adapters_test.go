@@ -0,0 +1,129 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestLimiter cria um rate limiter em memória com um relógio falso compartilhado com o
+// armazenamento. O rate limiter é fechado ao fim do teste.
+func newTestLimiter(t *testing.T, opts ...ratelimiter.Option) (*ratelimiter.RateLimiter, *clock.Fake) {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	opts = append(opts, ratelimiter.WithClock(fakeClock))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), opts...)
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter, fakeClock
+}
+
+func TestEvaluate(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, time.Minute),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "tenant", Limit: 1, Window: time.Minute}),
+	)
+
+	cfg := NewConfig(WithRouteRule("/tenants/:id", "tenant"), WithParams("id"))
+	ctx := context.Background()
+
+	request := func(tenant string) Request {
+		return Request{
+			IP:    "203.0.113.7",
+			Route: "/tenants/:id",
+			Param: func(name string) string { return tenant },
+		}
+	}
+
+	// A regra da rota limita cada tenant separadamente
+	if _, err := Evaluate(ctx, limiter, cfg, request("a")); err != nil {
+		t.Fatalf("deveria permitir primeira requisição, mas recebeu erro: %v", err)
+	}
+	decision, err := Evaluate(ctx, limiter, cfg, request("a"))
+	if !IsLimitExceeded(err) {
+		t.Fatalf("deveria negar segunda requisição do mesmo tenant, mas recebeu: %v", err)
+	}
+	if got := Headers(decision)["Retry-After"]; got != "60" {
+		t.Errorf("Retry-After deveria ser 60, mas recebeu %s", got)
+	}
+	if _, err := Evaluate(ctx, limiter, cfg, request("b")); err != nil {
+		t.Errorf("deveria permitir requisição de outro tenant, mas recebeu erro: %v", err)
+	}
+
+	// Rotas sem regra usam o limite por IP
+	decision, err = Evaluate(ctx, limiter, cfg, Request{IP: "203.0.113.7", Route: "/health"})
+	if err != nil {
+		t.Fatalf("deveria permitir requisição sem regra, mas recebeu erro: %v", err)
+	}
+	if decision.Rule != ratelimiter.IPRuleName {
+		t.Errorf("regra deveria ser %s, mas recebeu %s", ratelimiter.IPRuleName, decision.Rule)
+	}
+	if _, ok := Headers(decision)["Retry-After"]; ok {
+		t.Error("requisição permitida não deveria ter Retry-After")
+	}
+}
+
+func TestEvaluate_RouteCostAndPath(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, time.Minute),
+		ratelimiter.WithGlobalLimits(ratelimiter.GlobalLimit{Name: "export", PathPrefix: "/export", Limit: 10}),
+	)
+
+	cfg := NewConfig(WithRouteCost("/export/:id", 5), WithRoutePriority("/export/:id", ratelimiter.PriorityHigh))
+	ctx := context.Background()
+	req := Request{IP: "203.0.113.7", Path: "/export/1", Route: "/export/:id"}
+
+	// O custo da rota é cobrado do limite do cliente e do limite global do caminho
+	decision, err := Evaluate(ctx, limiter, cfg, req)
+	if err != nil {
+		t.Fatalf("deveria permitir primeira requisição, mas recebeu erro: %v", err)
+	}
+	if decision.Remaining != 95 {
+		t.Errorf("saldo deveria ser 95, mas recebeu %d", decision.Remaining)
+	}
+	if _, err := Evaluate(ctx, limiter, cfg, req); err != nil {
+		t.Fatalf("deveria permitir segunda requisição, mas recebeu erro: %v", err)
+	}
+	var globalErr *ratelimiter.GlobalLimitExceededError
+	if _, err := Evaluate(ctx, limiter, cfg, req); !errors.As(err, &globalErr) {
+		t.Errorf("erro deveria ser GlobalLimitExceededError, mas recebeu: %v", err)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithConcurrencyLimit(1, 1, time.Minute),
+	)
+
+	ctx := context.Background()
+	req := Request{IP: "203.0.113.7"}
+
+	release, err := Acquire(ctx, limiter, req)
+	if err != nil {
+		t.Fatalf("deveria reservar a primeira vaga, mas recebeu erro: %v", err)
+	}
+	_, err = Acquire(ctx, limiter, req)
+	if !IsLimitExceeded(err) {
+		t.Fatalf("deveria negar a segunda requisição simultânea, mas recebeu: %v", err)
+	}
+	if got := ConcurrencyHeaders(err)["Retry-After"]; got != "1" {
+		t.Errorf("Retry-After deveria ser 1, mas recebeu %q", got)
+	}
+	if headers := ConcurrencyHeaders(errors.New("falha")); headers != nil {
+		t.Errorf("outros erros não deveriam ter cabeçalhos, mas recebeu %v", headers)
+	}
+
+	// Liberada a vaga, uma nova requisição é aceita
+	release()
+	release, err = Acquire(ctx, limiter, req)
+	if err != nil {
+		t.Fatalf("deveria reservar a vaga liberada, mas recebeu erro: %v", err)
+	}
+	release()
+}
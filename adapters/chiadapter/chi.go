@@ -0,0 +1,101 @@
+// Package chiadapter integra o rate limiter ao roteador chi.
+package chiadapter
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// New retorna um middleware do chi que aplica o rate limiter. Middlewares registrados com
+// r.Use rodam antes do roteamento, então o padrão da rota e os parâmetros são resolvidos
+// com Routes.Find. O IP vem de r.RemoteAddr; use middleware.RealIP do chi atrás de proxies.
+func New(limiter *ratelimiter.RateLimiter, opts ...adapters.Option) func(http.Handler) http.Handler {
+	cfg := adapters.NewConfig(opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, params := routePattern(r)
+
+			req := adapters.Request{
+				IP:    remoteIP(r),
+				Token: r.Header.Get(cfg.TokenHeader),
+				Path:  r.URL.Path,
+				Route: route,
+				Param: params.URLParam,
+			}
+			// Reserva uma vaga de concorrência antes de consumir os limites; ela é liberada
+			// quando o handler termina ou quando a requisição é negada
+			release, err := adapters.Acquire(r.Context(), limiter, req)
+			if err != nil {
+				if adapters.IsLimitExceeded(err) {
+					for name, value := range adapters.ConcurrencyHeaders(err) {
+						w.Header().Set(name, value)
+					}
+					writeJSON(w, http.StatusTooManyRequests, err.Error())
+					return
+				}
+				writeJSON(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			defer release()
+
+			decision, err := adapters.Evaluate(r.Context(), limiter, cfg, req)
+			if err != nil && !adapters.IsLimitExceeded(err) {
+				writeJSON(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+
+			for name, value := range adapters.Headers(decision) {
+				w.Header().Set(name, value)
+			}
+
+			// Se o limite foi excedido, retorna 429 Too Many Requests
+			if err != nil {
+				writeJSON(w, http.StatusTooManyRequests, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routePattern retorna o padrão da rota e os parâmetros da requisição. Se o roteamento
+// ainda não aconteceu, procura a rota na árvore do roteador.
+func routePattern(r *http.Request) (string, *chi.Context) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "", chi.NewRouteContext()
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern, rctx
+	}
+
+	found := chi.NewRouteContext()
+	if rctx.Routes == nil {
+		return "", found
+	}
+	return rctx.Routes.Find(found, r.Method, r.URL.Path), found
+}
+
+// remoteIP extrai o IP do endereço da conexão
+func remoteIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// writeJSON envia uma resposta de erro em JSON
+func writeJSON(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,110 @@
+package chiadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestLimiter cria um rate limiter em memória com um relógio falso compartilhado com o
+// armazenamento. O rate limiter é fechado ao fim do teste.
+func newTestLimiter(t *testing.T, opts ...ratelimiter.Option) (*ratelimiter.RateLimiter, *clock.Fake) {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	opts = append(opts, ratelimiter.WithClock(fakeClock))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), opts...)
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter, fakeClock
+}
+
+func TestNew(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(2, time.Minute),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "users", Limit: 1, Window: time.Minute}),
+	)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("OK")) }
+
+	r := chi.NewRouter()
+	r.Use(New(limiter, adapters.WithRouteRule("/api/users/{id}", "users"), adapters.WithParams("id")))
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/users/{id}", ok)
+	})
+	r.Get("/health", ok)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// A regra da rota (inclusive em sub-roteadores) limita cada usuário separadamente
+	if rec := get("/api/users/1"); rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	if rec := get("/api/users/1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec := get("/api/users/2"); rec.Code != http.StatusOK {
+		t.Errorf("esperava status %d para outro usuário, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+
+	// As demais rotas usam o limite por IP
+	for i := 0; i < 2; i++ {
+		if rec := get("/health"); rec.Code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+		}
+	}
+	rec := get("/health")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("resposta 429 deveria ter Retry-After")
+	}
+}
+
+func TestNew_Concurrency(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, time.Minute),
+		ratelimiter.WithConcurrencyLimit(1, 1, time.Minute),
+	)
+
+	r := chi.NewRouter()
+	r.Use(New(limiter))
+	var inner *httptest.ResponseRecorder
+	r.Get("/slow", func(w http.ResponseWriter, req *http.Request) {
+		// Enquanto a requisição está em andamento, outra do mesmo IP é negada
+		if inner == nil {
+			inner = httptest.NewRecorder()
+			r.ServeHTTP(inner, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		}
+		w.Write([]byte("OK"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	if inner.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d para a requisição simultânea, mas recebeu %d", http.StatusTooManyRequests, inner.Code)
+	}
+
+	// Ao terminar, a vaga é liberada
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("esperava status %d após liberar a vaga, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+// Package echoadapter integra o rate limiter ao framework echo.
+package echoadapter
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// New retorna um middleware do echo que aplica o rate limiter. Registrado com e.Use, ele
+// roda após o roteamento, então o padrão da rota vem de c.Path() e os parâmetros de
+// c.Param; o IP vem de c.RealIP(), que respeita o IPExtractor configurado no echo.
+func New(limiter *ratelimiter.RateLimiter, opts ...adapters.Option) echo.MiddlewareFunc {
+	cfg := adapters.NewConfig(opts...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := adapters.Request{
+				IP:    c.RealIP(),
+				Token: c.Request().Header.Get(cfg.TokenHeader),
+				Path:  c.Request().URL.Path,
+				Route: c.Path(),
+				Param: c.Param,
+			}
+			// Reserva uma vaga de concorrência antes de consumir os limites; ela é liberada
+			// quando o handler termina ou quando a requisição é negada
+			release, err := adapters.Acquire(c.Request().Context(), limiter, req)
+			if err != nil {
+				if adapters.IsLimitExceeded(err) {
+					for name, value := range adapters.ConcurrencyHeaders(err) {
+						c.Response().Header().Set(name, value)
+					}
+					return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+				}
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Internal Server Error"})
+			}
+			defer release()
+
+			decision, err := adapters.Evaluate(c.Request().Context(), limiter, cfg, req)
+			if err != nil && !adapters.IsLimitExceeded(err) {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Internal Server Error"})
+			}
+
+			for name, value := range adapters.Headers(decision) {
+				c.Response().Header().Set(name, value)
+			}
+
+			// Se o limite foi excedido, retorna 429 Too Many Requests
+			if err != nil {
+				return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+			}
+
+			return next(c)
+		}
+	}
+}
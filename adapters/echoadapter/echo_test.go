@@ -0,0 +1,62 @@
+package echoadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestNew(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}),
+		ratelimiter.WithClock(fakeClock),
+		ratelimiter.WithIPLimit(2, time.Minute),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "users", Limit: 1, Window: time.Minute}),
+	)
+	defer limiter.Close()
+
+	e := echo.New()
+	e.Use(New(limiter, adapters.WithRouteRule("/users/:id", "users"), adapters.WithParams("id")))
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "OK") }
+	e.GET("/users/:id", ok)
+	e.GET("/health", ok)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// A regra da rota limita cada usuário separadamente
+	if rec := get("/users/1"); rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	if rec := get("/users/1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec := get("/users/2"); rec.Code != http.StatusOK {
+		t.Errorf("esperava status %d para outro usuário, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+
+	// As demais rotas usam o limite por IP
+	for i := 0; i < 2; i++ {
+		if rec := get("/health"); rec.Code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+		}
+	}
+	rec := get("/health")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("resposta 429 deveria ter Retry-After")
+	}
+}
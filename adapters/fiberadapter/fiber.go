@@ -0,0 +1,56 @@
+// Package fiberadapter integra o rate limiter ao framework fiber.
+package fiberadapter
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// New retorna um handler do fiber que aplica o rate limiter. O padrão da rota vem de
+// c.Route().Path, que no fiber é o padrão do handler em execução: para usar regras por
+// rota, registre o middleware na própria rota (app.Get("/users/:id", mw, handler)), já
+// que com app.Use o padrão é o do Use. O IP vem de c.IP(), que respeita ProxyHeader.
+func New(limiter *ratelimiter.RateLimiter, opts ...adapters.Option) fiber.Handler {
+	cfg := adapters.NewConfig(opts...)
+
+	return func(c *fiber.Ctx) error {
+		req := adapters.Request{
+			IP:    c.IP(),
+			Token: c.Get(cfg.TokenHeader),
+			Path:  c.Path(),
+			Route: c.Route().Path,
+			Param: func(name string) string { return c.Params(name) },
+		}
+		// Reserva uma vaga de concorrência antes de consumir os limites; ela é liberada
+		// quando os handlers seguintes terminam ou quando a requisição é negada
+		release, err := adapters.Acquire(c.UserContext(), limiter, req)
+		if err != nil {
+			if adapters.IsLimitExceeded(err) {
+				for name, value := range adapters.ConcurrencyHeaders(err) {
+					c.Set(name, value)
+				}
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal Server Error"})
+		}
+		defer release()
+
+		decision, err := adapters.Evaluate(c.UserContext(), limiter, cfg, req)
+		if err != nil && !adapters.IsLimitExceeded(err) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal Server Error"})
+		}
+
+		for name, value := range adapters.Headers(decision) {
+			c.Set(name, value)
+		}
+
+		// Se o limite foi excedido, retorna 429 Too Many Requests
+		if err != nil {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Next()
+	}
+}
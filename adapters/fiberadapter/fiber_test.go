@@ -0,0 +1,66 @@
+package fiberadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestNew(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}),
+		ratelimiter.WithClock(fakeClock),
+		ratelimiter.WithIPLimit(2, time.Minute),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "users", Limit: 1, Window: time.Minute}),
+	)
+	defer limiter.Close()
+
+	mw := New(limiter, adapters.WithRouteRule("/users/:id", "users"), adapters.WithParams("id"))
+	ok := func(c *fiber.Ctx) error { return c.SendString("OK") }
+
+	app := fiber.New()
+	app.Get("/users/:id", mw, ok)
+	app.Get("/health", mw, ok)
+
+	get := func(path string) *http.Response {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("erro ao fazer requisição: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// A regra da rota limita cada usuário separadamente
+	if resp := get("/users/1"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, resp.StatusCode)
+	}
+	if resp := get("/users/1"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if resp := get("/users/2"); resp.StatusCode != http.StatusOK {
+		t.Errorf("esperava status %d para outro usuário, mas recebeu %d", http.StatusOK, resp.StatusCode)
+	}
+
+	// As demais rotas usam o limite por IP
+	for i := 0; i < 2; i++ {
+		if resp := get("/health"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+	resp := get("/health")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("resposta 429 deveria ter Retry-After")
+	}
+}
@@ -0,0 +1,61 @@
+// Package ginadapter integra o rate limiter ao framework gin.
+package ginadapter
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// New retorna um middleware do gin que aplica o rate limiter. O padrão da rota vem de
+// c.FullPath() e os parâmetros de c.Param; o IP vem de c.ClientIP(), que respeita a
+// configuração de proxies confiáveis do gin.
+func New(limiter *ratelimiter.RateLimiter, opts ...adapters.Option) gin.HandlerFunc {
+	cfg := adapters.NewConfig(opts...)
+
+	return func(c *gin.Context) {
+		req := adapters.Request{
+			IP:    c.ClientIP(),
+			Token: c.GetHeader(cfg.TokenHeader),
+			Path:  c.Request.URL.Path,
+			Route: c.FullPath(),
+			Param: c.Param,
+		}
+		// Reserva uma vaga de concorrência antes de consumir os limites; ela é liberada
+		// quando os handlers seguintes terminam ou quando a requisição é negada
+		release, err := adapters.Acquire(c.Request.Context(), limiter, req)
+		if err != nil {
+			if adapters.IsLimitExceeded(err) {
+				for name, value := range adapters.ConcurrencyHeaders(err) {
+					c.Header(name, value)
+				}
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		defer release()
+
+		decision, err := adapters.Evaluate(c.Request.Context(), limiter, cfg, req)
+		if err != nil && !adapters.IsLimitExceeded(err) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+
+		for name, value := range adapters.Headers(decision) {
+			c.Header(name, value)
+		}
+
+		// Se o limite foi excedido, retorna 429 Too Many Requests
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
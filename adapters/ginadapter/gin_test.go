@@ -0,0 +1,67 @@
+package ginadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pperesbr/ratelimiter/adapters"
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestNew(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}),
+		ratelimiter.WithClock(fakeClock),
+		ratelimiter.WithIPLimit(2, time.Minute),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "users", Limit: 1, Window: time.Minute}),
+	)
+	defer limiter.Close()
+
+	router := gin.New()
+	router.Use(New(limiter, adapters.WithRouteRule("/users/:id", "users"), adapters.WithParams("id")))
+	router.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "OK") })
+	router.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "OK") })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// A regra da rota limita cada usuário separadamente
+	if rec := get("/users/1"); rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	if rec := get("/users/1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec := get("/users/2"); rec.Code != http.StatusOK {
+		t.Errorf("esperava status %d para outro usuário, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+
+	// As demais rotas usam o limite por IP
+	for i := 0; i < 2; i++ {
+		rec := get("/health")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("X-RateLimit-Limit deveria ser 2, mas recebeu %s", got)
+		}
+	}
+	rec := get("/health")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("resposta 429 deveria ter Retry-After")
+	}
+}
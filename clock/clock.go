@@ -0,0 +1,67 @@
+// Package clock abstrai a obtenção do horário atual, permitindo que testes
+// controlem a passagem do tempo de forma determinística.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock fornece o horário atual
+type Clock interface {
+	// Now retorna o horário atual
+	Now() time.Time
+}
+
+// realClock implementa Clock usando o relógio do sistema
+type realClock struct{}
+
+// Now retorna time.Now()
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// New retorna um Clock que usa o relógio do sistema
+func New() Clock {
+	return realClock{}
+}
+
+// OrDefault retorna c, ou o relógio do sistema se c for nil
+func OrDefault(c Clock) Clock {
+	if c == nil {
+		return New()
+	}
+	return c
+}
+
+// Fake é um Clock controlado manualmente, para testes
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake cria um Fake parado no instante informado
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now retorna o instante atual do relógio falso
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance avança o relógio falso pela duração informada
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Set posiciona o relógio falso no instante informado
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
@@ -2,19 +2,34 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
 	"github.com/pperesbr/ratelimiter/config"
+	"github.com/pperesbr/ratelimiter/events"
 	"github.com/pperesbr/ratelimiter/internal/handlers"
-	"github.com/pperesbr/ratelimiter/internal/middleware"
-	"github.com/pperesbr/ratelimiter/internal/ratelimiter"
-	"github.com/pperesbr/ratelimiter/internal/ratelimiter/store"
+	"github.com/pperesbr/ratelimiter/internal/proxy"
+	"github.com/pperesbr/ratelimiter/internal/rls"
+	"github.com/pperesbr/ratelimiter/middleware"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+	"google.golang.org/grpc"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
@@ -26,33 +41,242 @@ func main() {
 	switch cfg.StorageType {
 	case "redis":
 		storeFactory = func() (store.RateLimiterStore, error) {
-			return store.NewRedisStore(cfg)
+			return store.NewRedisStore(store.RedisStoreOptions{
+				Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
+				Password: cfg.RedisPassword,
+				DB:       cfg.RedisDB,
+			})
 		}
 	case "memory":
 		storeFactory = func() (store.RateLimiterStore, error) {
-			return store.NewMemoryStore(), nil
+			opts := store.MemoryStoreOptions{
+				CleanupInterval: cfg.MemoryCleanupInterval,
+				MaxKeys:         cfg.MemoryMaxKeys,
+			}
+			// Com mais de uma partição usa a implementação particionada
+			if cfg.MemoryShards != 1 {
+				return store.NewShardedMemoryStore(cfg.MemoryShards, opts), nil
+			}
+			return store.NewMemoryStoreWithOptions(opts), nil
+		}
+	case "bolt":
+		storeFactory = func() (store.RateLimiterStore, error) {
+			return store.NewBoltStore(store.BoltStoreOptions{
+				Path:               cfg.BoltPath,
+				CompactionInterval: cfg.BoltCompactionInterval,
+			})
+		}
+	case "sql":
+		storeFactory = func() (store.RateLimiterStore, error) {
+			// O nome do driver coincide com o dialeto (postgres, mysql ou sqlite)
+			db, err := sql.Open(cfg.SQLDriver, cfg.SQLDSN)
+			if err != nil {
+				return nil, err
+			}
+			// Se a criação falhar, o NewSQLStore fecha a conexão
+			return store.NewSQLStore(db, store.SQLStoreOptions{
+				Dialect:       cfg.SQLDriver,
+				PurgeInterval: cfg.SQLPurgeInterval,
+			})
+		}
+	case "memcached":
+		storeFactory = func() (store.RateLimiterStore, error) {
+			return store.NewMemcachedStore(store.MemcachedStoreOptions{
+				Addr: cfg.MemcachedAddr,
+			})
 		}
 	default:
 		log.Fatalf("Tipo de armazenamento não suportado: %s", cfg.StorageType)
 	}
 
+	limiterOpts := []ratelimiter.Option{
+		ratelimiter.WithIPLimit(cfg.RateLimitIP, cfg.RateLimitIPBlockTime),
+		ratelimiter.WithTokenLimit(cfg.RateLimitToken, cfg.RateLimitTokenBlockTime),
+	}
+
+	// Cotas de longo prazo por token, alinhadas ao calendário do fuso configurado
+	quotaLocation, err := time.LoadLocation(cfg.QuotaTimezone)
+	if err != nil {
+		log.Fatalf("Fuso horário das cotas inválido: %v", err)
+	}
+	limiterOpts = append(limiterOpts, ratelimiter.WithQuotaLocation(quotaLocation))
+
+	quotas := []ratelimiter.Quota{
+		{Name: "hourly", Limit: cfg.QuotaHourly, Period: ratelimiter.QuotaHourly},
+		{Name: "daily", Limit: cfg.QuotaDaily, Period: ratelimiter.QuotaDaily},
+		{Name: "monthly", Limit: cfg.QuotaMonthly, Period: ratelimiter.QuotaMonthly},
+	}
+	for _, quota := range quotas {
+		if quota.Limit > 0 {
+			limiterOpts = append(limiterOpts, ratelimiter.WithQuotas(ratelimiter.TokenLimit, quota))
+		}
+	}
+
+	// Limite de requisições simultâneas, suportado apenas pelos armazenamentos em memória e Redis
+	if cfg.ConcurrencyIP > 0 || cfg.ConcurrencyToken > 0 {
+		if cfg.StorageType != "memory" && cfg.StorageType != "redis" {
+			log.Fatalf("Limite de concorrência não suportado pelo armazenamento %s", cfg.StorageType)
+		}
+		limiterOpts = append(limiterOpts, ratelimiter.WithConcurrencyLimit(cfg.ConcurrencyIP, cfg.ConcurrencyToken, cfg.ConcurrencyLeaseTTL))
+	}
+
+	// Limite de banda em bytes por segundo, também restrito aos armazenamentos em memória e Redis
+	if cfg.BandwidthIP > 0 || cfg.BandwidthToken > 0 {
+		if cfg.StorageType != "memory" && cfg.StorageType != "redis" {
+			log.Fatalf("Limite de banda não suportado pelo armazenamento %s", cfg.StorageType)
+		}
+	}
+	if cfg.BandwidthIP > 0 {
+		limiterOpts = append(limiterOpts, ratelimiter.WithBandwidthLimit(ratelimiter.IPLimit, ratelimiter.BandwidthLimit{Rate: cfg.BandwidthIP, Burst: cfg.BandwidthBurst}))
+	}
+	if cfg.BandwidthToken > 0 {
+		limiterOpts = append(limiterOpts, ratelimiter.WithBandwidthLimit(ratelimiter.TokenLimit, ratelimiter.BandwidthLimit{Rate: cfg.BandwidthToken, Burst: cfg.BandwidthBurst}))
+	}
+
+	// Limites globais por prefixo de caminho, somando todos os clientes
+	globalLimits, err := ratelimiter.ParseGlobalLimits(cfg.GlobalLimits, cfg.GlobalMaxShare)
+	if err != nil {
+		log.Fatalf("Falha ao configurar limites globais: %v", err)
+	}
+	limiterOpts = append(limiterOpts, ratelimiter.WithGlobalLimits(globalLimits...))
+
+	// Capacidade dos limites globais reservada para as prioridades mais altas
+	sheddingPolicy, err := ratelimiter.ParseSheddingPolicy(cfg.PriorityReserved)
+	if err != nil {
+		log.Fatalf("Falha ao configurar política de descarte: %v", err)
+	}
+	limiterOpts = append(limiterOpts, ratelimiter.WithSheddingPolicy(sheddingPolicy))
+
+	// Limites adaptativos, ajustados pela latência e pelos erros observados pelo middleware
+	adaptiveRules := []struct {
+		rule           string
+		floor, ceiling int
+	}{
+		{ratelimiter.IPRuleName, cfg.AdaptiveIPFloor, cfg.AdaptiveIPCeiling},
+		{ratelimiter.TokenRuleName, cfg.AdaptiveTokenFloor, cfg.AdaptiveTokenCeiling},
+	}
+	for _, adaptive := range adaptiveRules {
+		if adaptive.ceiling > 0 {
+			limiterOpts = append(limiterOpts, ratelimiter.WithAdaptiveLimit(adaptive.rule, ratelimiter.AdaptiveConfig{
+				Floor:         adaptive.floor,
+				Ceiling:       adaptive.ceiling,
+				TargetLatency: cfg.AdaptiveTargetLatency,
+				MaxErrorRate:  cfg.AdaptiveMaxErrorRate,
+			}))
+		}
+	}
+
+	// Limites por horário do dia das regras de IP e token
+	scheduleLocation, err := time.LoadLocation(cfg.ScheduleTimezone)
+	if err != nil {
+		log.Fatalf("Fuso horário dos agendamentos inválido: %v", err)
+	}
+	schedules := []struct {
+		rule, value string
+	}{
+		{ratelimiter.IPRuleName, cfg.ScheduleIP},
+		{ratelimiter.TokenRuleName, cfg.ScheduleToken},
+	}
+	for _, s := range schedules {
+		schedule, err := ratelimiter.ParseSchedule(s.value, scheduleLocation)
+		if err != nil {
+			log.Fatalf("Falha ao configurar agendamento da regra %s: %v", s.rule, err)
+		}
+		if schedule != nil {
+			limiterOpts = append(limiterOpts, ratelimiter.WithSchedule(s.rule, schedule))
+		}
+	}
+
+	// Carrega as regras nomeadas, consultadas pelo serviço de decisão
+	if cfg.RulesFile != "" {
+		rules, err := ratelimiter.LoadRulesFile(cfg.RulesFile)
+		if err != nil {
+			log.Fatalf("Falha ao carregar regras: %v", err)
+		}
+		limiterOpts = append(limiterOpts, ratelimiter.WithRules(rules...))
+	}
+
+	// Barramento de eventos de bloqueio, entregues ao webhook e ao stream
+	var eventBus *ratelimiter.EventBus
+	if cfg.EventsWebhookURL != "" || cfg.EventsStream {
+		eventBus = ratelimiter.NewEventBus()
+		defer eventBus.Close()
+		limiterOpts = append(limiterOpts, ratelimiter.WithEvents(eventBus))
+	}
+	if cfg.EventsWebhookURL != "" {
+		webhookOpts := events.WebhookOptions{
+			URL:           cfg.EventsWebhookURL,
+			BatchSize:     cfg.EventsWebhookBatchSize,
+			FlushInterval: cfg.EventsWebhookFlush,
+			MaxRetries:    cfg.EventsWebhookRetries,
+		}
+		if cfg.EventsWebhookAuth != "" {
+			webhookOpts.Headers = map[string]string{"Authorization": cfg.EventsWebhookAuth}
+		}
+		for _, name := range strings.Split(cfg.EventsWebhookTypes, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				webhookOpts.Types = append(webhookOpts.Types, ratelimiter.EventType(name))
+			}
+		}
+		webhook, err := events.NewWebhook(eventBus, webhookOpts)
+		if err != nil {
+			log.Fatalf("Falha ao configurar webhook de eventos: %v", err)
+		}
+		defer webhook.Close()
+	}
+
 	// Cria rate limiter
-	limiter, err := ratelimiter.NewRateLimiter(cfg, storeFactory)
+	limiter, err := ratelimiter.NewRateLimiter(storeFactory, limiterOpts...)
 	if err != nil {
 		log.Fatalf("Falha ao criar rate limiter: %v", err)
 	}
 	defer limiter.Close()
 
-	// Cria middleware do rate limiter
-	rateLimiterMiddleware := middleware.NewRateLimiterMiddleware(limiter)
+	// Custos por rota e por requisição aplicados pelo middleware
+	routeCosts, err := parseRouteCosts(cfg.RouteCosts)
+	if err != nil {
+		log.Fatalf("Falha ao configurar custos por rota: %v", err)
+	}
+	middlewareOpts := make([]middleware.Option, 0, len(routeCosts)+1)
+	for pattern, cost := range routeCosts {
+		middlewareOpts = append(middlewareOpts, middleware.WithRouteCost(pattern, cost))
+	}
+	if cfg.CostHeader != "" {
+		middlewareOpts = append(middlewareOpts, middleware.WithCostFunc(middleware.HeaderCost(cfg.CostHeader)))
+	}
+	routePriorities, err := parseRoutePriorities(cfg.PriorityRoutes)
+	if err != nil {
+		log.Fatalf("Falha ao configurar prioridades por rota: %v", err)
+	}
+	for pattern, priority := range routePriorities {
+		middlewareOpts = append(middlewareOpts, middleware.WithRoutePriority(pattern, priority))
+	}
+	if cfg.PriorityHeader != "" {
+		middlewareOpts = append(middlewareOpts, middleware.WithPriorityFunc(middleware.HeaderPriority(cfg.PriorityHeader)))
+	}
+	if cfg.CountOnStatus != "" {
+		countFuncs, err := parseCountOnStatus(cfg.CountOnStatus)
+		if err != nil {
+			log.Fatalf("Falha ao configurar contagem por resposta: %v", err)
+		}
+		middlewareOpts = append(middlewareOpts, middleware.WithCountOn(countFuncs...))
+	}
+	if cfg.MaxWait > 0 {
+		middlewareOpts = append(middlewareOpts, middleware.WithWait(cfg.MaxWait, cfg.MaxQueue))
+	}
+	// Proxies confiáveis valem para o middleware e para o forward auth
+	var forwardAuthOpts []handlers.ForwardAuthOption
+	if cfg.TrustedProxies != "" {
+		trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+		if err != nil {
+			log.Fatalf("Falha ao configurar proxies confiáveis: %v", err)
+		}
+		middlewareOpts = append(middlewareOpts, middleware.WithTrustedProxies(trustedProxies...))
+		forwardAuthOpts = append(forwardAuthOpts, handlers.WithForwardTrustedProxies(trustedProxies...))
+	}
 
 	// Cria router e define rotas
 	r := mux.NewRouter()
-	r.Use(rateLimiterMiddleware.Middleware)
-
-	// Define os handlers
-	r.HandleFunc("/", handlers.HomeHandler()).Methods("GET")
-	r.HandleFunc("/test", handlers.TestHandler()).Methods("GET")
 
 	// Configuração do servidor
 	srv := &http.Server{
@@ -63,6 +287,96 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Com limite de banda, uma transferência atrasada pode durar mais que qualquer prazo
+	// total de leitura ou escrita; apenas a leitura dos cabeçalhos continua limitada
+	if cfg.BandwidthIP > 0 || cfg.BandwidthToken > 0 {
+		srv.ReadTimeout = 0
+		srv.WriteTimeout = 0
+		srv.ReadHeaderTimeout = 15 * time.Second
+	}
+
+	// Listener administrativo, separado das rotas públicas
+	if cfg.EventsStream && cfg.AdminPort == "" {
+		log.Fatalf("Falha ao configurar stream de eventos: EVENTS_STREAM requer ADMIN_PORT")
+	}
+	var adminSrv *http.Server
+	if cfg.AdminPort != "" {
+		admin := http.NewServeMux()
+		admin.Handle("/debug/vars", expvar.Handler())
+
+		// Stream de eventos para acompanhamento ao vivo. Os eventos trazem IPs, então o
+		// stream fica apenas no listener administrativo.
+		if cfg.EventsStream {
+			admin.Handle("GET /v1/events", events.StreamHandler(eventBus, 0))
+		}
+
+		adminSrv = &http.Server{
+			Addr:         ":" + cfg.AdminPort,
+			Handler:      admin,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		// Os streams de eventos só terminam quando o cliente desconecta ou o barramento é
+		// fechado; sem isso, Shutdown esperaria por eles até o prazo
+		if eventBus != nil {
+			adminSrv.RegisterOnShutdown(eventBus.Close)
+		}
+	}
+
+	// Publica os limites adaptativos em vigor em /debug/vars
+	if cfg.AdaptiveIPCeiling > 0 || cfg.AdaptiveTokenCeiling > 0 {
+		expvar.Publish("ratelimiter_adaptive_limits", expvar.Func(func() any {
+			return limiter.AdaptiveLimits()
+		}))
+	}
+
+	// Servidor gRPC compatível com o RateLimitService do Envoy (apenas no modo decision)
+	var grpcServer *grpc.Server
+
+	switch cfg.ServerMode {
+	case "middleware":
+		// Cria middleware do rate limiter
+		rateLimiterMiddleware := middleware.NewRateLimiterMiddleware(limiter, middlewareOpts...)
+		r.Use(rateLimiterMiddleware.Middleware)
+
+		// Define os handlers
+		r.HandleFunc("/", handlers.HomeHandler()).Methods("GET")
+		r.HandleFunc("/test", handlers.TestHandler()).Methods("GET")
+	case "decision":
+		// Serviço de decisão compartilhado: outros serviços consultam o limiter via HTTP
+		r.HandleFunc("/", handlers.HomeHandler()).Methods("GET")
+		r.HandleFunc("/v1/check", handlers.CheckHandler(limiter)).Methods("POST")
+
+		// Endpoint para auth_request do nginx e ForwardAuth do Traefik
+		r.HandleFunc("/v1/auth", handlers.ForwardAuthHandler(limiter, cfg.ForwardAuthDenyStatus, forwardAuthOpts...))
+
+		grpcServer = grpc.NewServer()
+		rlsv3.RegisterRateLimitServiceServer(grpcServer, rls.NewServer(limiter))
+	case "proxy":
+		// Sidecar: encaminha as requisições permitidas aos serviços de destino
+		routes, err := proxy.ParseRoutes(cfg.ProxyUpstream, cfg.ProxyRoutes)
+		if err != nil {
+			log.Fatalf("Falha ao configurar rotas do proxy: %v", err)
+		}
+		reverseProxy, err := proxy.New(routes, proxy.Options{Timeout: cfg.ProxyTimeout})
+		if err != nil {
+			log.Fatalf("Falha ao criar proxy: %v", err)
+		}
+
+		rateLimiterMiddleware := middleware.NewRateLimiterMiddleware(limiter, middlewareOpts...)
+		r.Use(rateLimiterMiddleware.Middleware)
+		r.PathPrefix("/").Handler(reverseProxy)
+
+		// Respostas em streaming e websockets não podem ter prazo total de leitura ou escrita
+		srv.ReadTimeout = 0
+		srv.WriteTimeout = 0
+		srv.ReadHeaderTimeout = 15 * time.Second
+	default:
+		log.Fatalf("Modo do servidor não suportado: %s", cfg.ServerMode)
+	}
+
 	// Inicia o servidor em uma goroutine separada
 	go func() {
 		log.Printf("Servidor iniciado na porta %s\n", cfg.ServerPort)
@@ -71,6 +385,30 @@ func main() {
 		}
 	}()
 
+	// Inicia o listener administrativo em uma goroutine separada
+	if adminSrv != nil {
+		go func() {
+			log.Printf("Servidor administrativo iniciado na porta %s\n", cfg.AdminPort)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Falha ao iniciar servidor administrativo: %v", err)
+			}
+		}()
+	}
+
+	// Inicia o servidor gRPC em uma goroutine separada
+	if grpcServer != nil {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			log.Fatalf("Falha ao abrir porta gRPC: %v", err)
+		}
+		go func() {
+			log.Printf("Servidor gRPC iniciado na porta %s\n", cfg.GRPCPort)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("Falha ao iniciar servidor gRPC: %v", err)
+			}
+		}()
+	}
+
 	// Configura graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -83,10 +421,112 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Encerra o servidor
+	// Encerra o servidor gRPC, aguardando as chamadas em andamento
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	// Encerra os servidores. Uma falha não interrompe o encerramento, para que os Close
+	// adiados (webhook, barramento, rate limiter) ainda sejam executados.
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Falha ao encerrar servidor: %v", err)
+		log.Printf("Falha ao encerrar servidor: %v", err)
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			log.Printf("Falha ao encerrar servidor administrativo: %v", err)
+		}
+	}
+
+	log.Println("Servidor encerrado")
+}
+
+// parseRouteCosts interpreta custos por rota no formato "GET /export/{id}=100,/bulk/=10"
+func parseRouteCosts(value string) (map[string]int, error) {
+	costs := make(map[string]int)
+	var patterns []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, cost, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("custo inválido: %s", entry)
+		}
+		n, err := strconv.Atoi(cost)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("custo inválido para %s: %s", pattern, cost)
+		}
+		pattern = strings.TrimSpace(pattern)
+		patterns = append(patterns, pattern)
+		costs[pattern] = n
+	}
+
+	// Os padrões são validados juntos, pois dois padrões válidos podem entrar em conflito
+	if err := middleware.ValidateRoutePatterns(patterns...); err != nil {
+		return nil, err
+	}
+
+	return costs, nil
+}
+
+// parseRoutePriorities converte uma lista no formato /checkout=high,GET /reports/=low em
+// prioridades por padrão de rota
+func parseRoutePriorities(value string) (map[string]ratelimiter.Priority, error) {
+	priorities := make(map[string]ratelimiter.Priority)
+	var patterns []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("prioridade inválida: %s", entry)
+		}
+		priority, err := ratelimiter.ParsePriority(name)
+		if err != nil {
+			return nil, fmt.Errorf("prioridade inválida para %s: %w", pattern, err)
+		}
+		pattern = strings.TrimSpace(pattern)
+		patterns = append(patterns, pattern)
+		priorities[pattern] = priority
+	}
+
+	if err := middleware.ValidateRoutePatterns(patterns...); err != nil {
+		return nil, err
+	}
+
+	return priorities, nil
+}
+
+// parseCountOnStatus converte uma lista no formato 401,403,5xx em funções de contagem por
+// status ou por classe de status
+func parseCountOnStatus(value string) ([]middleware.CountFunc, error) {
+	var funcs []middleware.CountFunc
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if class, ok := strings.CutSuffix(entry, "xx"); ok {
+			n, err := strconv.Atoi(class)
+			if err != nil || n < 1 || n > 5 {
+				return nil, fmt.Errorf("classe de status inválida: %s", entry)
+			}
+			funcs = append(funcs, middleware.StatusClass(n))
+			continue
+		}
+
+		status, err := strconv.Atoi(entry)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("status inválido: %s", entry)
+		}
+		funcs = append(funcs, middleware.StatusIn(status))
 	}
 
-	log.Println("Servidor encerrado com sucesso")
+	return funcs, nil
 }
@@ -12,6 +12,7 @@ import (
 // Config contém todas as configurações da aplicação
 type Config struct {
 	ServerPort              string
+	AdminPort               string
 	RateLimitIP             int
 	RateLimitIPBlockTime    time.Duration
 	RateLimitToken          int
@@ -21,6 +22,59 @@ type Config struct {
 	RedisPassword           string
 	RedisDB                 int
 	StorageType             string
+	MemoryMaxKeys           int
+	MemoryCleanupInterval   time.Duration
+	MemoryShards            int
+	BoltPath                string
+	BoltCompactionInterval  time.Duration
+	SQLDriver               string
+	SQLDSN                  string
+	SQLPurgeInterval        time.Duration
+	MemcachedAddr           string
+	ServerMode              string
+	TrustedProxies          string
+	RulesFile               string
+	GRPCPort                string
+	ForwardAuthDenyStatus   int
+	ProxyUpstream           string
+	ProxyRoutes             string
+	ProxyTimeout            time.Duration
+	RouteCosts              string
+	CostHeader              string
+	QuotaHourly             int
+	QuotaDaily              int
+	QuotaMonthly            int
+	QuotaTimezone           string
+	MaxWait                 time.Duration
+	MaxQueue                int
+	ConcurrencyIP           int
+	ConcurrencyToken        int
+	ConcurrencyLeaseTTL     time.Duration
+	BandwidthIP             int
+	BandwidthToken          int
+	BandwidthBurst          int
+	AdaptiveIPFloor         int
+	AdaptiveIPCeiling       int
+	AdaptiveTokenFloor      int
+	AdaptiveTokenCeiling    int
+	AdaptiveTargetLatency   time.Duration
+	AdaptiveMaxErrorRate    float64
+	ScheduleIP              string
+	ScheduleToken           string
+	ScheduleTimezone        string
+	GlobalLimits            string
+	GlobalMaxShare          float64
+	PriorityReserved        string
+	PriorityRoutes          string
+	PriorityHeader          string
+	CountOnStatus           string
+	EventsWebhookURL        string
+	EventsWebhookAuth       string
+	EventsWebhookTypes      string
+	EventsWebhookBatchSize  int
+	EventsWebhookFlush      time.Duration
+	EventsWebhookRetries    int
+	EventsStream            bool
 }
 
 // LoadConfig carrega a configuração do arquivo .env ou variáveis de ambiente
@@ -37,9 +91,39 @@ func LoadConfig() *Config {
 	rateLimitToken, _ := strconv.Atoi(getEnv("RATE_LIMIT_TOKEN", "10"))
 	rateLimitTokenBlockTime, _ := strconv.Atoi(getEnv("RATE_LIMIT_TOKEN_BLOCK_TIME", "5"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	memoryMaxKeys, _ := strconv.Atoi(getEnv("MEMORY_MAX_KEYS", "100000"))
+	memoryCleanupInterval, _ := strconv.Atoi(getEnv("MEMORY_CLEANUP_INTERVAL", "60"))
+	memoryShards, _ := strconv.Atoi(getEnv("MEMORY_SHARDS", "1"))
+	boltCompactionInterval, _ := strconv.Atoi(getEnv("BOLT_COMPACTION_INTERVAL", "60"))
+	sqlPurgeInterval, _ := strconv.Atoi(getEnv("SQL_PURGE_INTERVAL", "60"))
+	proxyTimeout, _ := strconv.Atoi(getEnv("PROXY_TIMEOUT", "30"))
+	quotaHourly, _ := strconv.Atoi(getEnv("QUOTA_HOURLY", "0"))
+	quotaDaily, _ := strconv.Atoi(getEnv("QUOTA_DAILY", "0"))
+	quotaMonthly, _ := strconv.Atoi(getEnv("QUOTA_MONTHLY", "0"))
+	maxWait, _ := strconv.Atoi(getEnv("RATE_LIMIT_MAX_WAIT", "0"))
+	maxQueue, _ := strconv.Atoi(getEnv("RATE_LIMIT_MAX_QUEUE", "100"))
+	concurrencyIP, _ := strconv.Atoi(getEnv("CONCURRENCY_LIMIT_IP", "0"))
+	concurrencyToken, _ := strconv.Atoi(getEnv("CONCURRENCY_LIMIT_TOKEN", "0"))
+	concurrencyLeaseTTL, _ := strconv.Atoi(getEnv("CONCURRENCY_LEASE_TTL", "60"))
+	bandwidthIP, _ := strconv.Atoi(getEnv("BANDWIDTH_LIMIT_IP", "0"))
+	bandwidthToken, _ := strconv.Atoi(getEnv("BANDWIDTH_LIMIT_TOKEN", "0"))
+	bandwidthBurst, _ := strconv.Atoi(getEnv("BANDWIDTH_BURST", "0"))
+	adaptiveIPFloor, _ := strconv.Atoi(getEnv("ADAPTIVE_IP_FLOOR", "1"))
+	adaptiveIPCeiling, _ := strconv.Atoi(getEnv("ADAPTIVE_IP_CEILING", "0"))
+	adaptiveTokenFloor, _ := strconv.Atoi(getEnv("ADAPTIVE_TOKEN_FLOOR", "1"))
+	adaptiveTokenCeiling, _ := strconv.Atoi(getEnv("ADAPTIVE_TOKEN_CEILING", "0"))
+	adaptiveTargetLatency, _ := strconv.Atoi(getEnv("ADAPTIVE_TARGET_LATENCY", "500"))
+	adaptiveMaxErrorRate, _ := strconv.ParseFloat(getEnv("ADAPTIVE_MAX_ERROR_RATE", "0.05"), 64)
+	globalMaxShare, _ := strconv.ParseFloat(getEnv("GLOBAL_MAX_SHARE", "0"), 64)
+	eventsWebhookBatchSize, _ := strconv.Atoi(getEnv("EVENTS_WEBHOOK_BATCH_SIZE", "100"))
+	eventsWebhookFlush, _ := strconv.Atoi(getEnv("EVENTS_WEBHOOK_FLUSH_INTERVAL", "1000"))
+	eventsWebhookRetries, _ := strconv.Atoi(getEnv("EVENTS_WEBHOOK_MAX_RETRIES", "3"))
+	eventsStream, _ := strconv.ParseBool(getEnv("EVENTS_STREAM", "false"))
+	forwardAuthDenyStatus, _ := strconv.Atoi(getEnv("FORWARD_AUTH_DENY_STATUS", "429"))
 
 	return &Config{
 		ServerPort:              getEnv("SERVER_PORT", "8080"),
+		AdminPort:               getEnv("ADMIN_PORT", ""),
 		RateLimitIP:             rateLimitIP,
 		RateLimitIPBlockTime:    time.Duration(rateLimitIPBlockTime) * time.Minute,
 		RateLimitToken:          rateLimitToken,
@@ -49,6 +133,59 @@ func LoadConfig() *Config {
 		RedisPassword:           getEnv("REDIS_PASSWORD", ""),
 		RedisDB:                 redisDB,
 		StorageType:             getEnv("STORAGE_TYPE", "redis"),
+		MemoryMaxKeys:           memoryMaxKeys,
+		MemoryCleanupInterval:   time.Duration(memoryCleanupInterval) * time.Second,
+		MemoryShards:            memoryShards,
+		BoltPath:                getEnv("BOLT_PATH", "ratelimiter.db"),
+		BoltCompactionInterval:  time.Duration(boltCompactionInterval) * time.Second,
+		SQLDriver:               getEnv("SQL_DRIVER", "sqlite"),
+		SQLDSN:                  getEnv("SQL_DSN", "ratelimiter.sqlite"),
+		SQLPurgeInterval:        time.Duration(sqlPurgeInterval) * time.Second,
+		MemcachedAddr:           getEnv("MEMCACHED_ADDR", "localhost:11211"),
+		ServerMode:              getEnv("SERVER_MODE", "middleware"),
+		TrustedProxies:          getEnv("TRUSTED_PROXIES", ""),
+		RulesFile:               getEnv("RULES_FILE", ""),
+		GRPCPort:                getEnv("GRPC_PORT", "8081"),
+		ForwardAuthDenyStatus:   forwardAuthDenyStatus,
+		ProxyUpstream:           getEnv("PROXY_UPSTREAM", ""),
+		ProxyRoutes:             getEnv("PROXY_ROUTES", ""),
+		ProxyTimeout:            time.Duration(proxyTimeout) * time.Second,
+		RouteCosts:              getEnv("ROUTE_COSTS", ""),
+		CostHeader:              getEnv("COST_HEADER", ""),
+		QuotaHourly:             quotaHourly,
+		QuotaDaily:              quotaDaily,
+		QuotaMonthly:            quotaMonthly,
+		QuotaTimezone:           getEnv("QUOTA_TIMEZONE", "UTC"),
+		MaxWait:                 time.Duration(maxWait) * time.Millisecond,
+		MaxQueue:                maxQueue,
+		ConcurrencyIP:           concurrencyIP,
+		ConcurrencyToken:        concurrencyToken,
+		ConcurrencyLeaseTTL:     time.Duration(concurrencyLeaseTTL) * time.Second,
+		BandwidthIP:             bandwidthIP,
+		BandwidthToken:          bandwidthToken,
+		BandwidthBurst:          bandwidthBurst,
+		AdaptiveIPFloor:         adaptiveIPFloor,
+		AdaptiveIPCeiling:       adaptiveIPCeiling,
+		AdaptiveTokenFloor:      adaptiveTokenFloor,
+		AdaptiveTokenCeiling:    adaptiveTokenCeiling,
+		AdaptiveTargetLatency:   time.Duration(adaptiveTargetLatency) * time.Millisecond,
+		AdaptiveMaxErrorRate:    adaptiveMaxErrorRate,
+		ScheduleIP:              getEnv("SCHEDULE_IP", ""),
+		ScheduleToken:           getEnv("SCHEDULE_TOKEN", ""),
+		ScheduleTimezone:        getEnv("SCHEDULE_TIMEZONE", "UTC"),
+		GlobalLimits:            getEnv("GLOBAL_LIMITS", ""),
+		GlobalMaxShare:          globalMaxShare,
+		PriorityReserved:        getEnv("PRIORITY_RESERVED", ""),
+		PriorityRoutes:          getEnv("PRIORITY_ROUTES", ""),
+		PriorityHeader:          getEnv("PRIORITY_HEADER", ""),
+		CountOnStatus:           getEnv("COUNT_ON_STATUS", ""),
+		EventsWebhookURL:        getEnv("EVENTS_WEBHOOK_URL", ""),
+		EventsWebhookAuth:       getEnv("EVENTS_WEBHOOK_AUTHORIZATION", ""),
+		EventsWebhookTypes:      getEnv("EVENTS_WEBHOOK_TYPES", ""),
+		EventsWebhookBatchSize:  eventsWebhookBatchSize,
+		EventsWebhookFlush:      time.Duration(eventsWebhookFlush) * time.Millisecond,
+		EventsWebhookRetries:    eventsWebhookRetries,
+		EventsStream:            eventsStream,
 	}
 }
 
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// DefaultHeartbeat é o intervalo padrão dos comentários enviados para manter o stream
+// aberto em proxies que encerram conexões ociosas
+const DefaultHeartbeat = 15 * time.Second
+
+// eventJSON é a representação JSON de um evento, com a duração em milissegundos
+type eventJSON struct {
+	Type       ratelimiter.EventType `json:"type"`
+	Key        string                `json:"key"`
+	Rule       string                `json:"rule"`
+	Count      int                   `json:"count"`
+	Limit      int                   `json:"limit"`
+	DurationMs int64                 `json:"duration_ms,omitempty"`
+	Time       time.Time             `json:"time"`
+}
+
+// newEventJSON converte um evento para a representação JSON
+func newEventJSON(event ratelimiter.Event) eventJSON {
+	return eventJSON{
+		Type:       event.Type,
+		Key:        event.Key,
+		Rule:       event.Rule,
+		Count:      event.Count,
+		Limit:      event.Limit,
+		DurationMs: event.Duration.Milliseconds(),
+		Time:       event.Time,
+	}
+}
+
+// StreamHandler retorna um handler de Server-Sent Events que transmite os eventos do
+// barramento enquanto o cliente estiver conectado. O parâmetro type filtra os tipos
+// (por exemplo, ?type=block,unblock). As chaves dos eventos contêm IPs (os tokens
+// aparecem apenas pela impressão digital): exponha o endpoint apenas para operadores. O
+// stream termina quando o barramento é fechado; registre o Close do barramento com
+// http.Server.RegisterOnShutdown para que Shutdown não espere pelos streams abertos.
+func StreamHandler(bus *ratelimiter.EventBus, heartbeat time.Duration) http.HandlerFunc {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var types []ratelimiter.EventType
+		for _, name := range strings.Split(r.URL.Query().Get("type"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				types = append(types, ratelimiter.EventType(name))
+			}
+		}
+
+		// O stream não tem prazo: remove o tempo limite de escrita do servidor, se houver
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{})
+
+		events, unsubscribe := bus.Subscribe(0)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if len(types) > 0 && !slices.Contains(types, event.Type) {
+					continue
+				}
+				data, err := json.Marshal(newEventJSON(event))
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
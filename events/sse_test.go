@@ -0,0 +1,93 @@
+package events
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestStreamHandler(t *testing.T) {
+	bus := ratelimiter.NewEventBus()
+	defer bus.Close()
+
+	server := httptest.NewServer(StreamHandler(bus, time.Hour))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?type=block")
+	if err != nil {
+		t.Fatalf("erro ao conectar ao stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type deveria ser text/event-stream, mas recebeu %s", ct)
+	}
+
+	// A assinatura existe assim que os cabeçalhos são enviados; o evento filtrado não é transmitido
+	bus.Publish(ratelimiter.Event{Type: ratelimiter.EventLimitExceeded, Key: "ip:1"})
+	bus.Publish(ratelimiter.Event{Type: ratelimiter.EventBlocked, Key: "ip:2", Rule: "ip", Count: 6, Limit: 5})
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case line := <-lines:
+			if line != "" {
+				got = append(got, line)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("deveria receber o evento, mas recebeu %v", got)
+		}
+	}
+
+	if got[0] != "event: block" {
+		t.Errorf("primeira linha deveria ser o tipo do evento, mas recebeu %s", got[0])
+	}
+	if !strings.HasPrefix(got[1], "data: ") || !strings.Contains(got[1], `"key":"ip:2"`) || !strings.Contains(got[1], `"count":6`) {
+		t.Errorf("dados do evento inesperados: %s", got[1])
+	}
+}
+
+func TestStreamHandler_BusClosed(t *testing.T) {
+	bus := ratelimiter.NewEventBus()
+
+	server := httptest.NewServer(StreamHandler(bus, time.Hour))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Fechar o barramento encerra o stream, como no Shutdown do servidor
+	bus.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := io.Copy(io.Discard, resp.Body)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("stream deveria terminar sem erro, mas recebeu: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream deveria terminar ao fechar o barramento")
+	}
+}
@@ -0,0 +1,228 @@
+// Package events entrega os eventos do rate limiter (limite excedido, bloqueio e fim de
+// bloqueio) para fora do processo, por webhooks HTTP ou por um stream de Server-Sent
+// Events.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// Valores usados quando os campos de WebhookOptions são zero
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+	DefaultMaxRetries    = 3
+	DefaultRetryBackoff  = 500 * time.Millisecond
+	DefaultTimeout       = 5 * time.Second
+)
+
+// WebhookOptions configura a entrega de eventos a um webhook
+type WebhookOptions struct {
+	// URL que recebe os eventos via POST
+	URL string
+	// Cabeçalhos adicionais das requisições (por exemplo, Authorization)
+	Headers map[string]string
+	// Tipos de evento entregues (vazio entrega todos)
+	Types []ratelimiter.EventType
+	// Número máximo de eventos por requisição (0 usa DefaultBatchSize)
+	BatchSize int
+	// Tempo máximo que um evento espera para completar um lote (0 usa DefaultFlushInterval)
+	FlushInterval time.Duration
+	// Número de novas tentativas após uma falha de entrega (0 usa DefaultMaxRetries;
+	// negativo desativa as novas tentativas)
+	MaxRetries int
+	// Espera antes da primeira nova tentativa, dobrada a cada tentativa (0 usa
+	// DefaultRetryBackoff)
+	RetryBackoff time.Duration
+	// Tempo limite de cada requisição (0 usa DefaultTimeout)
+	Timeout time.Duration
+	// Cliente HTTP usado nas entregas (nil cria um com Timeout)
+	Client *http.Client
+	// Tamanho do buffer da assinatura no barramento (0 usa ratelimiter.DefaultEventBuffer)
+	Buffer int
+}
+
+// webhookPayload é o corpo JSON enviado ao webhook
+type webhookPayload struct {
+	Events []eventJSON `json:"events"`
+}
+
+// Webhook assina um barramento de eventos e envia os eventos em lotes a uma URL, com
+// novas tentativas em caso de falha. Lotes que falham em todas as tentativas são
+// descartados e registrados no log.
+type Webhook struct {
+	opts        WebhookOptions
+	client      *http.Client
+	events      <-chan ratelimiter.Event
+	unsubscribe func()
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewWebhook valida as opções, assina o barramento e inicia a entrega dos eventos
+func NewWebhook(bus *ratelimiter.EventBus, opts WebhookOptions) (*Webhook, error) {
+	target, err := url.Parse(opts.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("URL do webhook inválida: %s", opts.URL)
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	events, unsubscribe := bus.Subscribe(opts.Buffer)
+	w := &Webhook{
+		opts:        opts,
+		client:      client,
+		events:      events,
+		unsubscribe: unsubscribe,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+// Close cancela a assinatura e envia o lote pendente (sem novas tentativas), aguardando
+// o fim da entrega
+func (w *Webhook) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		w.unsubscribe()
+	})
+	<-w.done
+	return nil
+}
+
+// run acumula os eventos em lotes, enviados quando o lote enche ou quando o evento mais
+// antigo espera FlushInterval
+func (w *Webhook) run() {
+	defer close(w.done)
+
+	var batch []ratelimiter.Event
+	timer := time.NewTimer(w.opts.FlushInterval)
+	timer.Stop()
+
+	flush := func() {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.send(batch); err != nil {
+			log.Printf("Falha ao entregar %d eventos ao webhook %s: %v", len(batch), w.opts.URL, err)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case event, ok := <-w.events:
+			if !ok {
+				flush()
+				return
+			}
+			if len(w.opts.Types) > 0 && !slices.Contains(w.opts.Types, event.Type) {
+				continue
+			}
+			if len(batch) == 0 {
+				timer.Reset(w.opts.FlushInterval)
+			}
+			batch = append(batch, event)
+			if len(batch) >= w.opts.BatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// send entrega um lote, repetindo em falhas de rede, respostas 5xx e 429 com espera
+// exponencial entre as tentativas
+func (w *Webhook) send(batch []ratelimiter.Event) error {
+	payload := webhookPayload{Events: make([]eventJSON, len(batch))}
+	for i, event := range batch {
+		payload.Events[i] = newEventJSON(event)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar eventos: %w", err)
+	}
+
+	backoff := w.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.opts.MaxRetries {
+			return err
+		}
+
+		// Durante o encerramento, não espera por novas tentativas
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// post faz uma tentativa de entrega e indica se uma falha pode ser repetida
+func (w *Webhook) post(body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = errors.New(resp.Status)
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestWebhook(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []eventJSON
+	)
+	delivered := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("cabeçalho Authorization deveria ser enviado, mas recebeu %q", r.Header.Get("Authorization"))
+		}
+		// A primeira tentativa falha e deve ser repetida
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("erro ao decodificar eventos: %v", err)
+		}
+		received = append(received, payload.Events...)
+		delivered <- struct{}{}
+	}))
+	defer server.Close()
+
+	bus := ratelimiter.NewEventBus()
+	defer bus.Close()
+	webhook, err := NewWebhook(bus, WebhookOptions{
+		URL:           server.URL,
+		Headers:       map[string]string{"Authorization": "Bearer secret"},
+		Types:         []ratelimiter.EventType{ratelimiter.EventBlocked},
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("erro ao criar webhook: %v", err)
+	}
+
+	// O evento de limite excedido é filtrado; os dois bloqueios formam um lote
+	bus.Publish(ratelimiter.Event{Type: ratelimiter.EventLimitExceeded, Key: "ip:1"})
+	bus.Publish(ratelimiter.Event{Type: ratelimiter.EventBlocked, Key: "ip:1", Duration: time.Minute})
+	bus.Publish(ratelimiter.Event{Type: ratelimiter.EventBlocked, Key: "ip:2", Duration: time.Minute})
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("deveria entregar o lote completo")
+	}
+
+	// Close entrega o lote incompleto pendente
+	bus.Publish(ratelimiter.Event{Type: ratelimiter.EventBlocked, Key: "ip:3"})
+	webhook.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("deveria fazer 3 requisições (uma repetida), mas fez %d", attempts)
+	}
+	if len(received) != 3 {
+		t.Fatalf("deveria entregar 3 eventos, mas entregou %d", len(received))
+	}
+	if received[0].Key != "ip:1" || received[0].Type != ratelimiter.EventBlocked || received[0].DurationMs != 60000 {
+		t.Errorf("evento inesperado: %+v", received[0])
+	}
+	if received[2].Key != "ip:3" {
+		t.Errorf("último evento deveria ser de ip:3, mas recebeu %+v", received[2])
+	}
+}
+
+func TestWebhook_InvalidURL(t *testing.T) {
+	bus := ratelimiter.NewEventBus()
+	defer bus.Close()
+
+	for _, target := range []string{"", "localhost:9000", "ftp://example.com"} {
+		if _, err := NewWebhook(bus, WebhookOptions{URL: target}); err == nil {
+			t.Errorf("deveria rejeitar a URL %q", target)
+		}
+	}
+}
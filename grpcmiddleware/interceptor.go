@@ -0,0 +1,240 @@
+// Package grpcmiddleware fornece interceptors gRPC que aplicam o rate limiter a cada
+// RPC ou a cada mensagem de stream.
+package grpcmiddleware
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// Chaves de metadata de onde o token de acesso é lido, em ordem de prioridade
+var defaultTokenKeys = []string{"api-key", "authorization"}
+
+// Interceptor aplica o rate limiter às chamadas gRPC
+type Interceptor struct {
+	limiter          *ratelimiter.RateLimiter
+	tokenKeys        []string
+	methodRules      map[string]string
+	methodCosts      map[string]int
+	methodPriorities map[string]ratelimiter.Priority
+	perMessage       bool
+}
+
+// Option configura parâmetros opcionais do interceptor
+type Option func(*Interceptor)
+
+// WithTokenKeys define as chaves de metadata de onde o token de acesso é lido
+func WithTokenKeys(keys ...string) Option {
+	return func(i *Interceptor) {
+		i.tokenKeys = keys
+	}
+}
+
+// WithMethodRule aplica uma regra nomeada a um método, identificado pelo nome completo
+// ("/pacote.Servico/Metodo") ou a todos os métodos de um serviço ("/pacote.Servico/*").
+// Métodos sem regra usam os limites por IP e token de RateLimiter.Allow.
+func WithMethodRule(method, rule string) Option {
+	return func(i *Interceptor) {
+		i.methodRules[method] = rule
+	}
+}
+
+// WithMethodCost define quanto as chamadas de um método ("/pacote.Servico/Metodo") ou de
+// todos os métodos de um serviço ("/pacote.Servico/*") consomem dos limites
+func WithMethodCost(method string, cost int) Option {
+	return func(i *Interceptor) {
+		i.methodCosts[method] = cost
+	}
+}
+
+// WithMethodPriority define a prioridade das chamadas de um método ou serviço nos limites
+// globais. Métodos sem prioridade usam PriorityNormal com token e PriorityLow sem token.
+func WithMethodPriority(method string, priority ratelimiter.Priority) Option {
+	return func(i *Interceptor) {
+		i.methodPriorities[method] = priority
+	}
+}
+
+// WithPerMessage aplica o limite a cada mensagem recebida em streams, em vez de
+// apenas na abertura do stream
+func WithPerMessage() Option {
+	return func(i *Interceptor) {
+		i.perMessage = true
+	}
+}
+
+// NewInterceptor cria uma nova instância do interceptor
+func NewInterceptor(limiter *ratelimiter.RateLimiter, opts ...Option) *Interceptor {
+	i := &Interceptor{
+		limiter:          limiter,
+		tokenKeys:        defaultTokenKeys,
+		methodRules:      make(map[string]string),
+		methodCosts:      make(map[string]int),
+		methodPriorities: make(map[string]ratelimiter.Priority),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// UnaryServerInterceptor retorna um interceptor que verifica o limite a cada RPC
+func (i *Interceptor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.allow(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor retorna um interceptor que verifica o limite na abertura do
+// stream ou, com WithPerMessage, a cada mensagem recebida
+func (i *Interceptor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if i.perMessage {
+			return handler(srv, &limitedStream{ServerStream: ss, interceptor: i, method: info.FullMethod})
+		}
+
+		if err := i.allow(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// limitedStream verifica o limite a cada mensagem recebida
+type limitedStream struct {
+	grpc.ServerStream
+	interceptor *Interceptor
+	method      string
+}
+
+// RecvMsg recebe a próxima mensagem e verifica o limite. Apenas mensagens recebidas com
+// sucesso são cobradas: o io.EOF do fim do stream e os erros de transporte não consomem
+// o limite.
+func (s *limitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.interceptor.allow(s.Context(), s.method)
+}
+
+// allow verifica o limite da chamada e converte a negação em um erro gRPC. O nome completo
+// do método é o caminho usado pelos limites globais. As mensagens dos erros são enviadas
+// ao cliente e, como as do rate limiter, ficam em inglês.
+func (i *Interceptor) allow(ctx context.Context, method string) error {
+	cost, _ := lookupMethod(i.methodCosts, method)
+	priority, _ := lookupMethod(i.methodPriorities, method)
+	req := &ratelimiter.LimiterRequest{
+		IP:       peerIP(ctx),
+		Token:    i.token(ctx),
+		Cost:     cost,
+		Path:     method,
+		Priority: priority,
+	}
+
+	var decision *ratelimiter.Decision
+	var err error
+	if rule, ok := lookupMethod(i.methodRules, method); ok {
+		decision, err = i.limiter.Check(ctx, &ratelimiter.CheckRequest{
+			Rule:        rule,
+			Descriptors: descriptors(method, req),
+			Cost:        cost,
+		})
+		if err == nil && !decision.Allowed {
+			return exhausted("rate limit exceeded for method "+method, decision)
+		}
+	} else {
+		decision, err = i.limiter.Decide(ctx, req)
+		if ratelimiter.IsRejected(err) {
+			return exhausted(err.Error(), decision)
+		}
+	}
+
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to check rate limit: %v", err)
+	}
+	return nil
+}
+
+// lookupMethod retorna o valor configurado para o método, procurando primeiro o nome
+// completo e depois o serviço ("/pacote.Servico/*")
+func lookupMethod[V any](values map[string]V, method string) (V, bool) {
+	if value, ok := values[method]; ok {
+		return value, true
+	}
+
+	if idx := strings.LastIndex(method, "/"); idx > 0 {
+		if value, ok := values[method[:idx+1]+"*"]; ok {
+			return value, true
+		}
+	}
+
+	var zero V
+	return zero, false
+}
+
+// token extrai o token de acesso da metadata, removendo o prefixo "Bearer "
+func (i *Interceptor) token(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	for _, key := range i.tokenKeys {
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return strings.TrimPrefix(values[0], "Bearer ")
+		}
+	}
+
+	return ""
+}
+
+// peerIP extrai o IP do cliente do endereço da conexão
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	addr := p.Addr.String()
+	ip, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return ip
+}
+
+// descriptors monta os descritores da regra do método: o token tem prioridade sobre o IP
+func descriptors(method string, req *ratelimiter.LimiterRequest) map[string]string {
+	if req.Token != "" {
+		return map[string]string{"method": method, "token": req.Token}
+	}
+	return map[string]string{"method": method, "ip": req.IP}
+}
+
+// exhausted cria um erro ResourceExhausted com RetryInfo indicando quando tentar novamente
+func exhausted(message string, decision *ratelimiter.Decision) error {
+	st := status.New(codes.ResourceExhausted, message)
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(decision.Reset),
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
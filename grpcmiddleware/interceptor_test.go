@@ -0,0 +1,265 @@
+package grpcmiddleware
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestLimiter cria um rate limiter em memória com relógio falso
+func newTestLimiter(t *testing.T, opts ...ratelimiter.Option) *ratelimiter.RateLimiter {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	opts = append(opts, ratelimiter.WithClock(fakeClock))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), opts...)
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter
+}
+
+// newHealthClient inicia um servidor de health check com os interceptors em uma
+// conexão em memória e retorna um cliente
+func newHealthClient(t *testing.T, interceptor *Interceptor) healthpb.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(interceptor.StreamServerInterceptor()),
+	)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao servidor: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	limiter := newTestLimiter(t, ratelimiter.WithIPLimit(2, time.Minute), ratelimiter.WithTokenLimit(3, time.Minute))
+	client := newHealthClient(t, NewInterceptor(limiter))
+	ctx := context.Background()
+
+	// Sem token, o limite é aplicado pelo IP do peer
+	for i := 0; i < 2; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("deveria permitir chamada %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("esperava código %v, mas recebeu %v", codes.ResourceExhausted, st.Code())
+	}
+
+	// O erro informa quando tentar novamente
+	var retryDelay time.Duration
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryDelay = info.GetRetryDelay().AsDuration()
+		}
+	}
+	if retryDelay != time.Minute {
+		t.Errorf("RetryInfo deveria indicar %v, mas recebeu %v", time.Minute, retryDelay)
+	}
+
+	// Com token na metadata, o limite do token tem prioridade
+	tokenCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer abc")
+	for i := 0; i < 3; i++ {
+		if _, err := client.Check(tokenCtx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("deveria permitir chamada %d com token, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	if _, err := client.Check(tokenCtx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("esperava código %v com token, mas recebeu %v", codes.ResourceExhausted, status.Code(err))
+	}
+}
+
+func TestUnaryServerInterceptor_MethodCostAndGlobalLimit(t *testing.T) {
+	limiter := newTestLimiter(t,
+		ratelimiter.WithTokenLimit(10, time.Minute),
+		ratelimiter.WithGlobalLimits(ratelimiter.GlobalLimit{Name: "health", PathPrefix: "/grpc.health.v1.Health", Limit: 6}),
+	)
+	client := newHealthClient(t, NewInterceptor(limiter,
+		WithMethodCost("/grpc.health.v1.Health/*", 3),
+		WithMethodPriority("/grpc.health.v1.Health/Check", ratelimiter.PriorityHigh),
+	))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "api-key", "abc")
+
+	// Cada chamada custa 3 no limite global do serviço, selecionado pelo nome do método
+	for i := 0; i < 2; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("deveria permitir chamada %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("limite global deveria negar a terceira chamada, mas recebeu %v", status.Code(err))
+	}
+}
+
+func TestUnaryServerInterceptor_MethodRule(t *testing.T) {
+	limiter := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, time.Minute),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "health", Limit: 1, Window: time.Second}),
+	)
+	client := newHealthClient(t, NewInterceptor(limiter, WithMethodRule("/grpc.health.v1.Health/*", "health")))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "api-key", "abc")
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("deveria permitir primeira chamada, mas recebeu erro: %v", err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("regra do serviço deveria limitar a segunda chamada, mas recebeu %v", status.Code(err))
+	}
+
+	// Outro token tem seu próprio contador
+	other := metadata.AppendToOutgoingContext(context.Background(), "api-key", "def")
+	if _, err := client.Check(other, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("deveria permitir chamada de outro token, mas recebeu erro: %v", err)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	limiter := newTestLimiter(t, ratelimiter.WithIPLimit(1, time.Minute))
+	client := newHealthClient(t, NewInterceptor(limiter))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// O primeiro stream é aceito
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("erro ao abrir stream: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("deveria receber mensagem do primeiro stream, mas recebeu erro: %v", err)
+	}
+
+	// O segundo excede o limite na abertura
+	stream, err = client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("erro ao abrir stream: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("esperava código %v, mas recebeu %v", codes.ResourceExhausted, status.Code(err))
+	}
+}
+
+// fakeStream é um grpc.ServerStream que recebe mensagens sem limite ou, com messages
+// definido, apenas essa quantidade antes de io.EOF
+type fakeStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	messages *int
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if s.messages == nil {
+		return nil
+	}
+	if *s.messages == 0 {
+		return io.EOF
+	}
+	*s.messages--
+	return nil
+}
+
+func TestStreamServerInterceptor_PerMessage(t *testing.T) {
+	limiter := newTestLimiter(t, ratelimiter.WithTokenLimit(3, time.Minute))
+	interceptor := NewInterceptor(limiter, WithPerMessage()).StreamServerInterceptor()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("api-key", "abc"))
+	info := &grpc.StreamServerInfo{FullMethod: "/chat.Chat/Talk", IsClientStream: true}
+
+	// O handler recebe mensagens até o limite ser excedido
+	var received int
+	err := interceptor(nil, &fakeStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(nil); err != nil {
+				return err
+			}
+			received++
+		}
+	})
+
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("esperava código %v, mas recebeu %v", codes.ResourceExhausted, status.Code(err))
+	}
+	if received != 3 {
+		t.Errorf("deveria receber 3 mensagens antes do limite, mas recebeu %d", received)
+	}
+}
+
+func TestStreamServerInterceptor_PerMessageEOFNotCharged(t *testing.T) {
+	limiter := newTestLimiter(t, ratelimiter.WithTokenLimit(2, time.Minute))
+	interceptor := NewInterceptor(limiter, WithPerMessage()).StreamServerInterceptor()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("api-key", "abc"))
+	info := &grpc.StreamServerInfo{FullMethod: "/chat.Chat/Talk", IsClientStream: true}
+	recv := func(ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Duas mensagens e o io.EOF do fim do stream consomem apenas duas requisições
+	messages := 2
+	err := interceptor(nil, &fakeStream{ctx: ctx, messages: &messages}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return recv(ss)
+	})
+	if err != io.EOF {
+		t.Fatalf("deveria terminar com io.EOF, mas recebeu: %v", err)
+	}
+
+	// Um stream sem mensagens também não consome o limite
+	messages = 0
+	err = interceptor(nil, &fakeStream{ctx: ctx, messages: &messages}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return recv(ss)
+	})
+	if err != io.EOF {
+		t.Fatalf("deveria terminar com io.EOF, mas recebeu: %v", err)
+	}
+
+	// A próxima mensagem excede o limite de duas
+	messages = 1
+	err = interceptor(nil, &fakeStream{ctx: ctx, messages: &messages}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return recv(ss)
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("esperava código %v, mas recebeu %v", codes.ResourceExhausted, status.Code(err))
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// CheckRequest representa o corpo JSON de uma consulta ao serviço de decisão
+type CheckRequest struct {
+	Rule        string            `json:"rule"`
+	Descriptors map[string]string `json:"descriptors"`
+	Cost        int               `json:"cost"`
+}
+
+// CheckResponse representa a decisão retornada pelo serviço de decisão
+type CheckResponse struct {
+	Allowed   bool   `json:"allowed"`
+	Rule      string `json:"rule"`
+	Limit     int    `json:"limit"`
+	Schedule  string `json:"schedule,omitempty"`
+	Remaining int    `json:"remaining"`
+	ResetMs   int64  `json:"reset_ms"`
+}
+
+// ErrorResponse representa um erro retornado em JSON
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// CheckHandler retorna um handler que avalia uma regra do rate limiter e responde com a
+// decisão. Requisições negadas também respondem 200: quem chama decide o que fazer.
+func CheckHandler(limiter *ratelimiter.RateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonResponse(w, ErrorResponse{Error: "corpo da requisição inválido"}, http.StatusBadRequest)
+			return
+		}
+		if req.Rule == "" {
+			jsonResponse(w, ErrorResponse{Error: "regra obrigatória"}, http.StatusBadRequest)
+			return
+		}
+
+		decision, err := limiter.Check(r.Context(), &ratelimiter.CheckRequest{
+			Rule:        req.Rule,
+			Descriptors: req.Descriptors,
+			Cost:        req.Cost,
+		})
+		switch {
+		case errors.Is(err, ratelimiter.ErrUnknownRule):
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+			return
+		case errors.Is(err, ratelimiter.ErrInvalidCost):
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			return
+		case err != nil:
+			jsonResponse(w, ErrorResponse{Error: "Internal Server Error"}, http.StatusInternalServerError)
+			return
+		}
+
+		jsonResponse(w, CheckResponse{
+			Allowed:   decision.Allowed,
+			Rule:      decision.Rule,
+			Limit:     decision.Limit,
+			Schedule:  decision.Schedule,
+			Remaining: decision.Remaining,
+			ResetMs:   decision.Reset.Milliseconds(),
+		}, http.StatusOK)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestLimiter cria um rate limiter em memória com um relógio falso compartilhado com o
+// armazenamento. O rate limiter é fechado ao fim do teste.
+func newTestLimiter(t *testing.T, opts ...ratelimiter.Option) (*ratelimiter.RateLimiter, *clock.Fake) {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	opts = append(opts, ratelimiter.WithClock(fakeClock))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), opts...)
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter, fakeClock
+}
+
+func TestCheckHandler(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "checkout", Limit: 3, Window: time.Minute}),
+	)
+
+	handler := CheckHandler(limiter)
+
+	check := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Primeira requisição de custo 2 é permitida
+	rec := check(`{"rule": "checkout", "descriptors": {"user": "42"}, "cost": 2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	var resp CheckResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("erro ao decodificar resposta: %v", err)
+	}
+	want := CheckResponse{Allowed: true, Rule: "checkout", Limit: 3, Remaining: 1, ResetMs: 60000}
+	if resp != want {
+		t.Errorf("resposta deveria ser %+v, mas recebeu %+v", want, resp)
+	}
+
+	// A segunda excede o limite: a decisão negada também responde 200
+	rec = check(`{"rule": "checkout", "descriptors": {"user": "42"}, "cost": 2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	resp = CheckResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("erro ao decodificar resposta: %v", err)
+	}
+	if resp.Allowed {
+		t.Error("deveria negar requisição acima do limite")
+	}
+
+	// Erros de validação e regra desconhecida
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"rule": "missing"}`, http.StatusNotFound},
+		{`{"descriptors": {"user": "42"}}`, http.StatusBadRequest},
+		{`{"rule": "checkout", "cost": -1}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := check(tt.body); rec.Code != tt.status {
+			t.Errorf("corpo %s: esperava status %d, mas recebeu %d", tt.body, tt.status, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/middleware"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// ForwardedRequest representa a requisição original repassada pelo proxy
+type ForwardedRequest struct {
+	IP     string
+	Method string
+	URI    string
+	Token  string
+}
+
+// ForwardAuthOption configura o ForwardAuthHandler
+type ForwardAuthOption func(*forwardAuth)
+
+// forwardAuth reúne a configuração usada para ler a requisição original
+type forwardAuth struct {
+	tokenHeader    string
+	trustedProxies []netip.Prefix
+}
+
+// WithForwardTokenHeader define o cabeçalho de onde o token de acesso é lido (o padrão
+// é middleware.DefaultTokenHeader, o mesmo do middleware)
+func WithForwardTokenHeader(header string) ForwardAuthOption {
+	return func(f *forwardAuth) {
+		f.tokenHeader = header
+	}
+}
+
+// WithForwardTrustedProxies define as redes dos proxies cujos cabeçalhos X-Forwarded-For
+// e X-Real-IP são aceitos (o padrão é middleware.DefaultTrustedProxies). Requisições de
+// outros endereços usam o IP da conexão.
+func WithForwardTrustedProxies(proxies ...netip.Prefix) ForwardAuthOption {
+	return func(f *forwardAuth) {
+		f.trustedProxies = proxies
+	}
+}
+
+// newForwardAuth aplica as opções sobre a configuração padrão
+func newForwardAuth(opts ...ForwardAuthOption) *forwardAuth {
+	f := &forwardAuth{
+		tokenHeader:    middleware.DefaultTokenHeader,
+		trustedProxies: middleware.DefaultTrustedProxies,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// ParseForwardedRequest extrai a requisição original dos cabeçalhos enviados pelo
+// nginx (auth_request) ou pelo Traefik (ForwardAuth). O IP do cliente é resolvido como
+// no middleware: os cabeçalhos de encaminhamento só valem quando a conexão vem de um
+// proxy confiável.
+func ParseForwardedRequest(r *http.Request, opts ...ForwardAuthOption) ForwardedRequest {
+	return newForwardAuth(opts...).parse(r)
+}
+
+// parse extrai a requisição original com a configuração informada
+func (f *forwardAuth) parse(r *http.Request) ForwardedRequest {
+	return ForwardedRequest{
+		IP:     middleware.ClientIP(r, f.trustedProxies),
+		Method: firstHeader(r, "X-Forwarded-Method", "X-Original-Method"),
+		URI:    firstHeader(r, "X-Forwarded-Uri", "X-Original-URI"),
+		Token:  r.Header.Get(f.tokenHeader),
+	}
+}
+
+// Path retorna o caminho da URI original, sem a query string
+func (f ForwardedRequest) Path() string {
+	path, _, _ := strings.Cut(f.URI, "?")
+	return path
+}
+
+// ForwardAuthHandler retorna um handler compatível com o auth_request do nginx e o
+// ForwardAuth do Traefik. Responde 200 quando a requisição original é permitida e
+// denyStatus quando o limite é excedido, sempre com os cabeçalhos de rate limit.
+func ForwardAuthHandler(limiter *ratelimiter.RateLimiter, denyStatus int, opts ...ForwardAuthOption) http.HandlerFunc {
+	config := newForwardAuth(opts...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		forwarded := config.parse(r)
+
+		decision, err := limiter.Decide(r.Context(), &ratelimiter.LimiterRequest{
+			IP:    forwarded.IP,
+			Token: forwarded.Token,
+			Path:  forwarded.Path(),
+		})
+
+		if err != nil && !ratelimiter.IsRejected(err) {
+			jsonResponse(w, ErrorResponse{Error: "Internal Server Error"}, http.StatusInternalServerError)
+			return
+		}
+
+		SetRateLimitHeaders(w, decision)
+		if err != nil {
+			w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(decision.Reset), 10))
+			jsonResponse(w, ErrorResponse{Error: err.Error()}, denyStatus)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// SetRateLimitHeaders adiciona os cabeçalhos X-RateLimit-* com base na decisão
+func SetRateLimitHeaders(w http.ResponseWriter, decision *ratelimiter.Decision) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(decision.Reset), 10))
+	for name, value := range ratelimiter.QuotaHeaders(decision) {
+		w.Header().Set(name, value)
+	}
+}
+
+// ceilSeconds arredonda uma duração para cima em segundos inteiros
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// firstHeader retorna o primeiro cabeçalho não vazio entre os informados
+func firstHeader(r *http.Request, names ...string) string {
+	for _, name := range names {
+		if value := r.Header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestForwardAuthHandler(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(2, time.Minute),
+	)
+
+	handler := ForwardAuthHandler(limiter, http.StatusTooManyRequests)
+
+	auth := func(ip string) *httptest.ResponseRecorder {
+		// Chamada feita pelo proxy (confiável), que acrescenta o cliente ao X-Forwarded-For
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1, "+ip)
+		req.Header.Set("X-Forwarded-Method", "POST")
+		req.Header.Set("X-Forwarded-Uri", "/orders")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Dentro do limite: 200 com os cabeçalhos de rate limit
+	for i := 0; i < 2; i++ {
+		rec := auth("203.0.113.7")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != []string{"1", "0"}[i] {
+			t.Errorf("X-RateLimit-Remaining deveria ser %s, mas recebeu %s", []string{"1", "0"}[i], got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("X-RateLimit-Limit deveria ser 2, mas recebeu %s", got)
+		}
+	}
+
+	// Acima do limite: 429 com Retry-After igual ao tempo de bloqueio
+	rec := auth("203.0.113.7")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After deveria ser 60, mas recebeu %s", got)
+	}
+
+	// Outro cliente não é afetado
+	if rec := auth("203.0.113.8"); rec.Code != http.StatusOK {
+		t.Errorf("esperava status %d para outro cliente, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestForwardAuthHandler_DenyStatus(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithTokenLimit(1, time.Minute),
+	)
+
+	// O nginx só aceita 401 ou 403 como negação no auth_request
+	handler := ForwardAuthHandler(limiter, http.StatusForbidden)
+
+	statuses := []int{http.StatusOK, http.StatusForbidden}
+	for i, want := range statuses {
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth", nil)
+		req.Header.Set("X-Real-IP", "203.0.113.7")
+		req.Header.Set("X-Original-URI", "/orders")
+		req.Header.Set("API_KEY", "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("requisição %d: esperava status %d, mas recebeu %d", i+1, want, rec.Code)
+		}
+	}
+}
+
+func TestParseForwardedRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Original-Method", "DELETE")
+	req.Header.Set("X-Original-URI", "/users/1")
+	req.Header.Set("API_KEY", "abc")
+
+	got := ParseForwardedRequest(req)
+	want := ForwardedRequest{IP: "10.0.0.1", Method: "DELETE", URI: "/users/1", Token: "abc"}
+	if got != want {
+		t.Errorf("requisição repassada deveria ser %+v, mas recebeu %+v", want, got)
+	}
+}
+
+func TestParseForwardedRequest_TokenHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth", nil)
+	req.Header.Set("API_KEY", "abc")
+	req.Header.Set("X-Api-Key", "def")
+
+	if got := ParseForwardedRequest(req, WithForwardTokenHeader("X-Api-Key")).Token; got != "def" {
+		t.Errorf("token deveria ser def, mas recebeu %s", got)
+	}
+}
+
+func TestParseForwardedRequest_ClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"último endereço não confiável de X-Forwarded-For", "10.0.0.1:5000", map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"X-Real-IP sem X-Forwarded-For", "10.0.0.1:5000", map[string]string{"X-Real-IP": "203.0.113.7"}, "203.0.113.7"},
+		{"cabeçalhos ignorados de conexão não confiável", "198.51.100.9:5000", map[string]string{"X-Real-IP": "203.0.113.7", "X-Forwarded-For": "1.2.3.4"}, "198.51.100.9"},
+		{"endereço da conexão", "10.0.0.1:5000", nil, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth", nil)
+		req.RemoteAddr = tt.remote
+		for name, value := range tt.headers {
+			req.Header.Set(name, value)
+		}
+
+		if got := ParseForwardedRequest(req).IP; got != tt.want {
+			t.Errorf("%s: IP deveria ser %s, mas recebeu %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestParseForwardedRequest_TrustedProxies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Real-IP", "203.0.113.7")
+
+	// Sem proxies confiáveis, os cabeçalhos são ignorados
+	if got := ParseForwardedRequest(req, WithForwardTrustedProxies()).IP; got != "10.0.0.1" {
+		t.Errorf("IP deveria ser 10.0.0.1, mas recebeu %s", got)
+	}
+}
@@ -0,0 +1,171 @@
+// Package proxy implementa o modo proxy reverso do servidor, em que o rate limiter
+// roda como sidecar na frente de um ou mais serviços.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Route associa um prefixo de caminho a um serviço de destino. O prefixo é comparado por
+// segmentos: /api atende /api e /api/users, mas não /apiv2
+type Route struct {
+	// Prefixo do caminho ("/" atende todas as requisições)
+	Prefix string
+	// URL do serviço de destino
+	Upstream *url.URL
+}
+
+// Options configura o proxy reverso
+type Options struct {
+	// Tempo máximo para conectar ao destino e receber os cabeçalhos da resposta.
+	// O corpo não tem limite de tempo, permitindo respostas em streaming e websockets.
+	Timeout time.Duration
+}
+
+// Proxy encaminha cada requisição ao destino da rota com o maior prefixo correspondente
+type Proxy struct {
+	routes []route
+}
+
+// route é uma rota com seu proxy reverso
+type route struct {
+	prefix string
+	proxy  *httputil.ReverseProxy
+}
+
+// New cria um proxy reverso para as rotas informadas
+func New(routes []Route, opts Options) (*Proxy, error) {
+	if len(routes) == 0 {
+		return nil, errors.New("nenhuma rota de proxy configurada")
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   opts.Timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ResponseHeaderTimeout: opts.Timeout,
+		TLSHandshakeTimeout:   opts.Timeout,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+
+	p := &Proxy{}
+	for _, r := range routes {
+		if r.Upstream == nil || r.Upstream.Scheme == "" || r.Upstream.Host == "" {
+			return nil, fmt.Errorf("destino inválido para a rota %s", r.Prefix)
+		}
+		p.routes = append(p.routes, route{
+			prefix: r.Prefix,
+			proxy:  newReverseProxy(r.Upstream, transport),
+		})
+	}
+
+	// Prefixos mais longos têm prioridade
+	sort.SliceStable(p.routes, func(i, j int) bool {
+		return len(p.routes[i].prefix) > len(p.routes[j].prefix)
+	})
+
+	return p, nil
+}
+
+// ServeHTTP encaminha a requisição ao destino da rota correspondente
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, route := range p.routes {
+		if matchPrefix(r.URL.Path, route.prefix) {
+			route.proxy.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+// matchPrefix indica se o caminho pertence ao prefixo, respeitando os segmentos do
+// caminho: /api atende /api e /api/users, mas não /apiv2
+func matchPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// newReverseProxy cria o proxy reverso de um destino
+func newReverseProxy(target *url.URL, transport http.RoundTripper) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+
+			// Mantém a cadeia de proxies anteriores e acrescenta o IP do cliente
+			pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			pr.SetXForwarded()
+		},
+		Transport: transport,
+		// Envia cada trecho da resposta imediatamente (streaming e server-sent events)
+		FlushInterval: -1,
+		ErrorHandler:  errorHandler,
+	}
+}
+
+// errorHandler responde 504 quando o destino excede o tempo limite e 502 nos demais erros
+func errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	// O cliente desistiu da requisição; não há a quem responder
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	log.Printf("Falha ao encaminhar %s %s: %v", r.Method, r.URL.Path, err)
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		http.Error(w, "Gateway Timeout", http.StatusGatewayTimeout)
+		return
+	}
+	http.Error(w, "Bad Gateway", http.StatusBadGateway)
+}
+
+// ParseRoutes monta as rotas a partir de um destino padrão (atende "/") e de uma lista
+// de rotas por caminho no formato "/api=http://api:8080,/admin=http://admin:9000"
+func ParseRoutes(upstream, routes string) ([]Route, error) {
+	var result []Route
+
+	if upstream != "" {
+		target, err := url.Parse(upstream)
+		if err != nil {
+			return nil, fmt.Errorf("destino padrão inválido: %w", err)
+		}
+		result = append(result, Route{Prefix: "/", Upstream: target})
+	}
+
+	for _, entry := range strings.Split(routes, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, upstream, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("rota inválida: %s", entry)
+		}
+		target, err := url.Parse(upstream)
+		if err != nil {
+			return nil, fmt.Errorf("destino inválido para a rota %s: %w", prefix, err)
+		}
+		result = append(result, Route{Prefix: prefix, Upstream: target})
+	}
+
+	return result, nil
+}
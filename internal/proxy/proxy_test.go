@@ -0,0 +1,233 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newProxyServer cria um servidor de teste com o proxy para as rotas informadas
+func newProxyServer(t *testing.T, routes string, opts Options) *httptest.Server {
+	t.Helper()
+
+	parsed, err := ParseRoutes("", routes)
+	if err != nil {
+		t.Fatalf("erro ao interpretar rotas: %v", err)
+	}
+	p, err := New(parsed, opts)
+	if err != nil {
+		t.Fatalf("erro ao criar proxy: %v", err)
+	}
+
+	server := httptest.NewServer(p)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxy_Routes(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "api %s xff=%s host=%s", r.URL.Path, r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer api.Close()
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "web %s", r.URL.Path)
+	}))
+	defer web.Close()
+
+	server := newProxyServer(t, fmt.Sprintf("/=%s,/api=%s", web.URL, api.URL), Options{Timeout: time.Second})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/users", "api /api/users xff=198.51.100.1, 127.0.0.1 host=" + strings.TrimPrefix(server.URL, "http://")},
+		{"/index.html", "web /index.html"},
+		{"/api", "api /api xff=198.51.100.1, 127.0.0.1 host=" + strings.TrimPrefix(server.URL, "http://")},
+		{"/apiv2/users", "web /apiv2/users"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+		if err != nil {
+			t.Fatalf("erro ao criar requisição: %v", err)
+		}
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("erro ao fazer requisição: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != tt.want {
+			t.Errorf("%s: resposta deveria ser %q, mas recebeu %q", tt.path, tt.want, body)
+		}
+	}
+}
+
+func TestProxy_NoRoute(t *testing.T) {
+	api := httptest.NewServer(http.NotFoundHandler())
+	defer api.Close()
+
+	server := newProxyServer(t, "/api="+api.URL, Options{Timeout: time.Second})
+
+	resp, err := http.Get(server.URL + "/other")
+	if err != nil {
+		t.Fatalf("erro ao fazer requisição: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestProxy_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	defer close(done)
+
+	server := newProxyServer(t, "/="+slow.URL, Options{Timeout: 50 * time.Millisecond})
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("erro ao fazer requisição: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusGatewayTimeout, resp.StatusCode)
+	}
+}
+
+func TestProxy_Streaming(t *testing.T) {
+	release := make(chan struct{})
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "primeiro")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprintln(w, "segundo")
+	}))
+	defer stream.Close()
+
+	server := newProxyServer(t, "/="+stream.URL, Options{Timeout: time.Second})
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("erro ao fazer requisição: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// O primeiro trecho chega antes de o destino terminar a resposta
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("erro ao ler primeiro trecho: %v", err)
+	}
+	if line != "primeiro\n" {
+		t.Errorf("primeiro trecho deveria ser %q, mas recebeu %q", "primeiro\n", line)
+	}
+
+	close(release)
+	rest, _ := io.ReadAll(reader)
+	if string(rest) != "segundo\n" {
+		t.Errorf("segundo trecho deveria ser %q, mas recebeu %q", "segundo\n", rest)
+	}
+}
+
+func TestProxy_Upgrade(t *testing.T) {
+	// Destino que aceita o upgrade e ecoa as linhas recebidas
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade esperado", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		rw.WriteString("eco: " + line)
+		rw.Flush()
+	}))
+	defer echo.Close()
+
+	server := newProxyServer(t, "/="+echo.URL, Options{Timeout: time.Second})
+
+	u, _ := url.Parse(server.URL)
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao proxy: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n", u.Host)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("erro ao ler resposta do upgrade: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+
+	// Após o upgrade, a conexão é repassada nos dois sentidos
+	fmt.Fprint(conn, "ola\n")
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("erro ao ler eco: %v", err)
+	}
+	if line != "eco: ola\n" {
+		t.Errorf("eco deveria ser %q, mas recebeu %q", "eco: ola\n", line)
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes("http://app:8080", "/api=http://api:9000, /admin=https://admin")
+	if err != nil {
+		t.Fatalf("erro ao interpretar rotas: %v", err)
+	}
+
+	want := []string{"/=http://app:8080", "/api=http://api:9000", "/admin=https://admin"}
+	if len(routes) != len(want) {
+		t.Fatalf("deveria interpretar %d rotas, mas recebeu %d", len(want), len(routes))
+	}
+	for i, r := range routes {
+		if got := r.Prefix + "=" + r.Upstream.String(); got != want[i] {
+			t.Errorf("rota %d deveria ser %s, mas recebeu %s", i, want[i], got)
+		}
+	}
+
+	for _, invalid := range []string{"api=http://api", "/api"} {
+		if _, err := ParseRoutes("", invalid); err == nil {
+			t.Errorf("deveria rejeitar a rota %q", invalid)
+		}
+	}
+
+	if _, err := New(nil, Options{}); err == nil {
+		t.Error("deveria rejeitar proxy sem rotas")
+	}
+}
@@ -0,0 +1,164 @@
+// Package rls implementa a API envoy.service.ratelimit.v3.RateLimitService sobre o
+// RateLimiter, permitindo que gateways Envoy apliquem os limites configurados.
+package rls
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// Server implementa ShouldRateLimit. Cada descritor é associado à regra cujo nome é
+// formado pelas chaves de suas entradas unidas por ponto (por exemplo,
+// "generic_key.remote_address"), procurando primeiro a regra prefixada pelo domínio
+// ("<domínio>/generic_key.remote_address"). Descritores sem regra não são limitados, e
+// descritores com chaves repetidas são rejeitados, já que cada chave identifica um valor
+// do contador.
+type Server struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+
+	limiter *ratelimiter.RateLimiter
+}
+
+// NewServer cria um novo servidor de rate limit para o Envoy
+func NewServer(limiter *ratelimiter.RateLimiter) *Server {
+	return &Server{
+		limiter: limiter,
+	}
+}
+
+// ShouldRateLimit avalia todos os descritores da requisição. A resposta geral é
+// OVER_LIMIT se qualquer descritor exceder o limite.
+func (s *Server) ShouldRateLimit(ctx context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	if len(req.GetDescriptors()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "nenhum descritor informado")
+	}
+
+	// Valida todos os descritores antes de consumir qualquer limite
+	for _, descriptor := range req.GetDescriptors() {
+		if key, ok := duplicateKey(descriptor); ok {
+			return nil, status.Errorf(codes.InvalidArgument, "chave %q repetida no descritor", key)
+		}
+	}
+
+	resp := &rlsv3.RateLimitResponse{
+		OverallCode: rlsv3.RateLimitResponse_OK,
+		Statuses:    make([]*rlsv3.RateLimitResponse_DescriptorStatus, 0, len(req.GetDescriptors())),
+	}
+
+	for _, descriptor := range req.GetDescriptors() {
+		decision, err := s.check(ctx, req.GetDomain(), descriptor, hitsAddend(req, descriptor))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "erro ao verificar limite: %v", err)
+		}
+
+		// Sem regra para o descritor: não há limite a aplicar
+		if decision == nil {
+			resp.Statuses = append(resp.Statuses, &rlsv3.RateLimitResponse_DescriptorStatus{
+				Code: rlsv3.RateLimitResponse_OK,
+			})
+			continue
+		}
+
+		descriptorStatus := &rlsv3.RateLimitResponse_DescriptorStatus{
+			Code:               rlsv3.RateLimitResponse_OK,
+			CurrentLimit:       currentLimit(decision),
+			LimitRemaining:     uint32(decision.Remaining),
+			DurationUntilReset: durationpb.New(decision.Reset),
+		}
+		if !decision.Allowed {
+			descriptorStatus.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+			resp.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		}
+		resp.Statuses = append(resp.Statuses, descriptorStatus)
+	}
+
+	return resp, nil
+}
+
+// check procura a regra do descritor e a avalia. Retorna nil se nenhuma regra corresponde.
+func (s *Server) check(ctx context.Context, domain string, descriptor *ratelimitv3.RateLimitDescriptor, cost int) (*ratelimiter.Decision, error) {
+	keys := make([]string, 0, len(descriptor.GetEntries()))
+	values := make(map[string]string, len(descriptor.GetEntries()))
+	for _, entry := range descriptor.GetEntries() {
+		keys = append(keys, entry.GetKey())
+		values[entry.GetKey()] = entry.GetValue()
+	}
+	name := strings.Join(keys, ".")
+
+	candidates := []string{name}
+	if domain != "" {
+		candidates = []string{domain + "/" + name, name}
+	}
+
+	for _, rule := range candidates {
+		decision, err := s.limiter.Check(ctx, &ratelimiter.CheckRequest{
+			Rule:        rule,
+			Descriptors: values,
+			Cost:        cost,
+		})
+		if errors.Is(err, ratelimiter.ErrUnknownRule) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return decision, nil
+	}
+
+	return nil, nil
+}
+
+// duplicateKey retorna a primeira chave que aparece mais de uma vez nas entradas do descritor
+func duplicateKey(descriptor *ratelimitv3.RateLimitDescriptor) (string, bool) {
+	seen := make(map[string]struct{}, len(descriptor.GetEntries()))
+	for _, entry := range descriptor.GetEntries() {
+		if _, ok := seen[entry.GetKey()]; ok {
+			return entry.GetKey(), true
+		}
+		seen[entry.GetKey()] = struct{}{}
+	}
+	return "", false
+}
+
+// hitsAddend retorna o custo do descritor: o valor do próprio descritor tem prioridade
+// sobre o da requisição, e zero equivale a um
+func hitsAddend(req *rlsv3.RateLimitRequest, descriptor *ratelimitv3.RateLimitDescriptor) int {
+	if descriptor.GetHitsAddend() != nil {
+		return int(descriptor.GetHitsAddend().GetValue())
+	}
+	return int(req.GetHitsAddend())
+}
+
+// currentLimit converte a regra da decisão para o formato do Envoy. Janelas que não
+// correspondem a uma unidade do Envoy são reportadas como UNKNOWN.
+func currentLimit(decision *ratelimiter.Decision) *rlsv3.RateLimitResponse_RateLimit {
+	unit := rlsv3.RateLimitResponse_RateLimit_UNKNOWN
+	switch decision.Window {
+	case time.Second:
+		unit = rlsv3.RateLimitResponse_RateLimit_SECOND
+	case time.Minute:
+		unit = rlsv3.RateLimitResponse_RateLimit_MINUTE
+	case time.Hour:
+		unit = rlsv3.RateLimitResponse_RateLimit_HOUR
+	case 24 * time.Hour:
+		unit = rlsv3.RateLimitResponse_RateLimit_DAY
+	case 7 * 24 * time.Hour:
+		unit = rlsv3.RateLimitResponse_RateLimit_WEEK
+	}
+
+	return &rlsv3.RateLimitResponse_RateLimit{
+		Name:            decision.Rule,
+		RequestsPerUnit: uint32(decision.Limit),
+		Unit:            unit,
+	}
+}
@@ -0,0 +1,153 @@
+package rls
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestClient inicia o servidor em uma conexão em memória e retorna um cliente
+func newTestClient(t *testing.T, limiter *ratelimiter.RateLimiter) rlsv3.RateLimitServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	rlsv3.RegisterRateLimitServiceServer(srv, NewServer(limiter))
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao servidor: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return rlsv3.NewRateLimitServiceClient(conn)
+}
+
+// descriptor cria um descritor do Envoy a partir de pares chave/valor
+func descriptor(pairs ...string) *ratelimitv3.RateLimitDescriptor {
+	d := &ratelimitv3.RateLimitDescriptor{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		d.Entries = append(d.Entries, &ratelimitv3.RateLimitDescriptor_Entry{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return d
+}
+
+func TestServer_ShouldRateLimit(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	limiter := ratelimiter.New(
+		store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}),
+		ratelimiter.WithRules(
+			ratelimiter.Rule{Name: "remote_address", Limit: 2, Window: time.Minute},
+			ratelimiter.Rule{Name: "edge/remote_address", Limit: 1, Window: time.Second},
+		),
+		ratelimiter.WithClock(fakeClock),
+	)
+	defer limiter.Close()
+
+	client := newTestClient(t, limiter)
+	ctx := context.Background()
+
+	req := &rlsv3.RateLimitRequest{
+		Domain: "api",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			descriptor("remote_address", "10.0.0.1"),
+			descriptor("path", "/health"),
+		},
+	}
+
+	// Dentro do limite; o descritor sem regra não é limitado
+	for i := 0; i < 2; i++ {
+		resp, err := client.ShouldRateLimit(ctx, req)
+		if err != nil {
+			t.Fatalf("erro ao chamar ShouldRateLimit: %v", err)
+		}
+		if resp.GetOverallCode() != rlsv3.RateLimitResponse_OK {
+			t.Fatalf("requisição %d deveria ser OK, mas recebeu %v", i+1, resp.GetOverallCode())
+		}
+
+		limited := resp.GetStatuses()[0]
+		if limited.GetLimitRemaining() != uint32(1-i) {
+			t.Errorf("restante deveria ser %d, mas recebeu %d", 1-i, limited.GetLimitRemaining())
+		}
+		if limited.GetCurrentLimit().GetUnit() != rlsv3.RateLimitResponse_RateLimit_MINUTE {
+			t.Errorf("unidade deveria ser MINUTE, mas recebeu %v", limited.GetCurrentLimit().GetUnit())
+		}
+		if resp.GetStatuses()[1].GetCurrentLimit() != nil {
+			t.Error("descritor sem regra não deveria ter limite")
+		}
+	}
+
+	// Acima do limite
+	resp, err := client.ShouldRateLimit(ctx, req)
+	if err != nil {
+		t.Fatalf("erro ao chamar ShouldRateLimit: %v", err)
+	}
+	if resp.GetOverallCode() != rlsv3.RateLimitResponse_OVER_LIMIT {
+		t.Errorf("deveria ser OVER_LIMIT, mas recebeu %v", resp.GetOverallCode())
+	}
+	if reset := resp.GetStatuses()[0].GetDurationUntilReset().AsDuration(); reset != time.Minute {
+		t.Errorf("reset deveria ser %v, mas recebeu %v", time.Minute, reset)
+	}
+	if resp.GetStatuses()[1].GetCode() != rlsv3.RateLimitResponse_OK {
+		t.Error("descritor sem regra deveria continuar OK")
+	}
+
+	// A regra prefixada pelo domínio tem prioridade
+	edgeReq := &rlsv3.RateLimitRequest{
+		Domain:      "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{descriptor("remote_address", "10.0.0.2")},
+		HitsAddend:  2,
+	}
+	resp, err = client.ShouldRateLimit(ctx, edgeReq)
+	if err != nil {
+		t.Fatalf("erro ao chamar ShouldRateLimit: %v", err)
+	}
+	if resp.GetOverallCode() != rlsv3.RateLimitResponse_OVER_LIMIT {
+		t.Errorf("custo 2 deveria exceder o limite 1 da regra do domínio, mas recebeu %v", resp.GetOverallCode())
+	}
+}
+
+func TestServer_ShouldRateLimitWithoutDescriptors(t *testing.T) {
+	limiter := ratelimiter.New(store.NewMemoryStore())
+	defer limiter.Close()
+
+	client := newTestClient(t, limiter)
+	if _, err := client.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{Domain: "api"}); err == nil {
+		t.Error("deveria rejeitar requisição sem descritores")
+	}
+}
+
+func TestServer_ShouldRateLimitDuplicateKeys(t *testing.T) {
+	limiter := ratelimiter.New(store.NewMemoryStore(),
+		ratelimiter.WithRules(ratelimiter.Rule{Name: "header_match.header_match", Limit: 1, Window: time.Minute}),
+	)
+	defer limiter.Close()
+
+	client := newTestClient(t, limiter)
+	_, err := client.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{descriptor("header_match", "a", "header_match", "b")},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("descritor com chave repetida deveria retornar InvalidArgument, mas recebeu: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestRateLimiterMiddleware_Adaptive(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		ratelimiter.WithIPLimit(8, 0),
+		ratelimiter.WithAdaptiveLimit(ratelimiter.IPRuleName, ratelimiter.AdaptiveConfig{Floor: 2, Ceiling: 10}),
+	)
+
+	// O backend falha até o teste marcá-lo como saudável
+	healthy := false
+	handler := NewRateLimiterMiddleware(limiter).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+
+	get := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	currentLimit := func() int {
+		return limiter.AdaptiveLimits()[0].Limit
+	}
+
+	// Respostas 5xx reduzem o limite pela metade ao fim do intervalo
+	get()
+	fakeClock.Advance(time.Second)
+	healthy = true
+	get()
+	if got := currentLimit(); got != 4 {
+		t.Fatalf("limite após respostas 5xx deveria ser 4, mas recebeu %d", got)
+	}
+
+	// Respostas com sucesso (inclusive sem WriteHeader explícito) aumentam o limite
+	fakeClock.Advance(time.Second)
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+	}
+	if got := currentLimit(); got != 5 {
+		t.Errorf("limite após intervalo saudável deveria ser 5, mas recebeu %d", got)
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// bandwidthMeter reserva banda no rate limiter e aguarda a espera retornada
+type bandwidthMeter struct {
+	ctx     context.Context
+	limiter *ratelimiter.RateLimiter
+	req     *ratelimiter.LimiterRequest
+	chunk   int
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// take reserva n bytes e aguarda até que possam ser transferidos
+func (b *bandwidthMeter) take(n int) error {
+	wait, err := b.limiter.ReserveBandwidth(b.ctx, b.req, n)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return b.sleep(b.ctx, wait)
+	}
+	return nil
+}
+
+// throttledReader limita a taxa de leitura do corpo da requisição
+type throttledReader struct {
+	io.ReadCloser
+	meter *bandwidthMeter
+}
+
+// Read lê no máximo um bloco e aguarda a banda dos bytes lidos
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > r.meter.chunk {
+		p = p[:r.meter.chunk]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.meter.take(n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttledWriter limita a taxa de escrita do corpo da resposta
+type throttledWriter struct {
+	http.ResponseWriter
+	meter *bandwidthMeter
+}
+
+// Write escreve em blocos, aguardando a banda de cada um antes de enviá-lo
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), w.meter.chunk)]
+		if err := w.meter.take(len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Flush repassa o flush para respostas em streaming
+func (w *throttledWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack repassa o controle da conexão. Conexões assumidas pelo handler, como
+// websockets, não passam pelo limite de banda.
+func (w *throttledWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap permite que http.ResponseController acesse o ResponseWriter original
+func (w *throttledWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// throttle envolve o corpo da requisição e o ResponseWriter para limitar a banda da chave.
+// Retorna os originais se a requisição não tem limite de banda.
+func (m *RateLimiterMiddleware) throttle(w http.ResponseWriter, r *http.Request, req *ratelimiter.LimiterRequest) (http.ResponseWriter, *http.Request) {
+	chunk := m.limiter.BandwidthChunk(req)
+	if chunk <= 0 {
+		return w, r
+	}
+
+	meter := &bandwidthMeter{
+		ctx:     r.Context(),
+		limiter: m.limiter,
+		req:     req,
+		chunk:   chunk,
+		sleep:   m.sleep,
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r = r.WithContext(r.Context())
+		r.Body = &throttledReader{ReadCloser: r.Body, meter: meter}
+	}
+	return &throttledWriter{ResponseWriter: w, meter: meter}, r
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestRateLimiterMiddleware_Bandwidth(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, 0),
+		// 1000 bytes por segundo, com rajada de 500 bytes
+		ratelimiter.WithBandwidthLimit(ratelimiter.IPLimit, ratelimiter.BandwidthLimit{Rate: 1000, Burst: 500}),
+	)
+
+	// O sleep apenas avança o relógio falso, registrando o tempo total de espera
+	var waited time.Duration
+	m := NewRateLimiterMiddleware(limiter)
+	m.sleep = func(ctx context.Context, d time.Duration) error {
+		waited += d
+		fakeClock.Advance(d)
+		return nil
+	}
+
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("erro ao ler corpo: %v", err)
+		}
+		// Devolve o corpo recebido duas vezes
+		w.Write(body)
+		w.Write(body)
+	}))
+
+	// 1500 bytes de upload e 3000 de download: 4500 bytes, dos quais 500 saem da rajada
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 1500)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+	if rec.Body.Len() != 3000 {
+		t.Errorf("resposta deveria ter 3000 bytes, mas recebeu %d", rec.Body.Len())
+	}
+	if waited < 3900*time.Millisecond || waited > 4100*time.Millisecond {
+		t.Errorf("a transferência deveria esperar cerca de 4s, mas esperou %v", waited)
+	}
+
+	// Um token sem limite de banda não espera
+	waited = 0
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 1500)))
+	req.Header.Set("API_KEY", "abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if waited != 0 {
+		t.Errorf("requisição sem limite de banda não deveria esperar, mas esperou %v", waited)
+	}
+}
+
+func TestThrottledWriter_Unwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := &throttledWriter{ResponseWriter: rec}
+
+	// O flush chega ao ResponseWriter original, preservando respostas em streaming
+	if err := http.NewResponseController(writer).Flush(); err != nil {
+		t.Fatalf("erro ao fazer flush: %v", err)
+	}
+	if !rec.Flushed {
+		t.Error("flush deveria chegar ao ResponseWriter original")
+	}
+}
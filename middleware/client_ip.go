@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultTrustedProxies são as redes cujos cabeçalhos de encaminhamento são aceitos por
+// padrão: loopback e redes privadas, onde ficam os proxies e balanceadores da própria
+// infraestrutura. Conexões vindas de outros endereços usam o IP da conexão.
+var DefaultTrustedProxies = []netip.Prefix{
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+// WithTrustedProxies define as redes dos proxies cujos cabeçalhos X-Forwarded-For e
+// X-Real-IP são aceitos (o padrão é DefaultTrustedProxies). Sem redes, os cabeçalhos são
+// sempre ignorados e o IP vem da conexão.
+func WithTrustedProxies(proxies ...netip.Prefix) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.trustedProxies = proxies
+	}
+}
+
+// ParseTrustedProxies converte uma lista de redes no formato 10.0.0.0/8,192.0.2.10 (um
+// endereço sem máscara equivale a uma rede com apenas ele)
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	proxies := []netip.Prefix{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("rede de proxy inválida: %s", entry)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("endereço de proxy inválido: %s", entry)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return proxies, nil
+}
+
+// ClientIP extrai o IP do cliente. Os cabeçalhos de encaminhamento só são considerados
+// quando a conexão vem de um proxy confiável: o X-Forwarded-For é percorrido da direita
+// para a esquerda, pulando os proxies confiáveis, e o primeiro endereço não confiável é o
+// do cliente (os anteriores foram enviados pelo próprio cliente e podem ser forjados).
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !isTrusted(remote, trusted) {
+		return remote
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap().String()
+		if !isTrusted(client, trusted) {
+			return client
+		}
+	}
+	// Todos os endereços são de proxies confiáveis: o mais distante é o cliente
+	if client != "" {
+		return client
+	}
+
+	// Proxies que não usam X-Forwarded-For
+	for _, header := range []string{"X-Real-IP", "X-Client-IP"} {
+		if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(header))); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+
+	return remote
+}
+
+// isTrusted indica se o endereço pertence a uma das redes confiáveis
+func isTrusted(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
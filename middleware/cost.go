@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// CostFunc calcula quanto uma requisição consome do limite. Valores menores que um
+// são tratados como um.
+type CostFunc func(r *http.Request) int
+
+// WithRouteCost define um custo fixo para as requisições que correspondem a um padrão
+// no formato do http.ServeMux (por exemplo, "GET /export/{id}" ou "/bulk/"). Quando mais
+// de um padrão corresponde, vale o mais específico, como no ServeMux. Padrões inválidos
+// ou em conflito com outro padrão de WithRouteCost são ignorados e o erro é retornado
+// por Err; valide padrões vindos da configuração com ValidateRoutePatterns.
+func WithRouteCost(pattern string, cost int) Option {
+	return func(m *RateLimiterMiddleware) {
+		if err := m.routeCosts.set(pattern, cost); err != nil {
+			m.setErr(err)
+		}
+	}
+}
+
+// WithCostFunc calcula o custo das requisições que não correspondem a nenhum padrão de
+// WithRouteCost
+func WithCostFunc(fn CostFunc) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.costFunc = fn
+	}
+}
+
+// HeaderCost lê o custo de um cabeçalho numérico. Valores ausentes ou inválidos custam um.
+func HeaderCost(header string) CostFunc {
+	return func(r *http.Request) int {
+		cost, err := strconv.Atoi(r.Header.Get(header))
+		if err != nil {
+			return 1
+		}
+		return cost
+	}
+}
+
+// BodySizeCost cobra uma unidade a cada bytesPerUnit bytes do corpo, arredondando para
+// cima. O custo é calculado antes da leitura do corpo, então requisições sem
+// Content-Length (como as com Transfer-Encoding: chunked) pagam o custo de maxBytes e
+// têm o corpo limitado a maxBytes: a leitura além disso falha com *http.MaxBytesError.
+// Requisições sem corpo custam um.
+func BodySizeCost(bytesPerUnit, maxBytes int64) CostFunc {
+	return func(r *http.Request) int {
+		if bytesPerUnit <= 0 {
+			return 1
+		}
+
+		size := r.ContentLength
+		if size < 0 {
+			size = maxBytes
+			r.Body = http.MaxBytesReader(nil, r.Body, maxBytes)
+		}
+		if size <= 0 {
+			return 1
+		}
+		return int((size + bytesPerUnit - 1) / bytesPerUnit)
+	}
+}
+
+// cost calcula o custo de uma requisição: o padrão de rota correspondente, depois a
+// função de custo e, por fim, um
+func (m *RateLimiterMiddleware) cost(r *http.Request) int {
+	if cost, ok := m.routeCosts.match(r); ok {
+		return max(cost, 1)
+	}
+
+	if m.costFunc != nil {
+		return max(m.costFunc(r), 1)
+	}
+
+	return 1
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestRateLimiterMiddleware_Cost(t *testing.T) {
+	m := NewRateLimiterMiddleware(nil,
+		WithRouteCost("GET /export/{id}", 100),
+		WithRouteCost("/bulk/", 10),
+		WithRouteCost("/bulk/", 20),
+		WithCostFunc(HeaderCost("X-Request-Cost")),
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		want   int
+	}{
+		{"padrão com método", http.MethodGet, "/export/42", "", 100},
+		{"método diferente do padrão", http.MethodPost, "/export/42", "", 1},
+		{"padrão de prefixo atualizado", http.MethodPost, "/bulk/users", "", 20},
+		{"custo do cabeçalho", http.MethodGet, "/items", "5", 5},
+		{"cabeçalho inválido", http.MethodGet, "/items", "abc", 1},
+		{"cabeçalho negativo", http.MethodGet, "/items", "-3", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-Cost", tt.header)
+			}
+
+			if got := m.cost(req); got != tt.want {
+				t.Errorf("custo deveria ser %d, mas recebeu %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBodySizeCost(t *testing.T) {
+	cost := BodySizeCost(1024, 8*1024)
+
+	tests := []struct {
+		size int
+		want int
+	}{
+		{0, 1},
+		{1, 1},
+		{1024, 1},
+		{1025, 2},
+		{10 * 1024, 10},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", tt.size)))
+		if got := cost(req); got != tt.want {
+			t.Errorf("corpo de %d bytes: custo deveria ser %d, mas recebeu %d", tt.size, tt.want, got)
+		}
+	}
+}
+
+func TestBodySizeCost_UnknownLength(t *testing.T) {
+	cost := BodySizeCost(1024, 8*1024)
+
+	// Sem Content-Length (chunked), paga o máximo e o corpo é limitado a ele
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 10*1024)))
+	req.ContentLength = -1
+	if got := cost(req); got != 8 {
+		t.Errorf("custo deveria ser 8, mas recebeu %d", got)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		t.Fatalf("leitura além do máximo deveria retornar *http.MaxBytesError, mas recebeu %v", err)
+	}
+	if len(body) != 8*1024 {
+		t.Errorf("corpo lido deveria ter %d bytes, mas tinha %d", 8*1024, len(body))
+	}
+}
+
+func TestRateLimiterMiddleware_CostConsumesBudget(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(10, time.Minute),
+	)
+
+	handler := NewRateLimiterMiddleware(limiter, WithRouteCost("/export", 6)).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// Uma exportação consome 6 das 10 unidades; quatro requisições simples esgotam o resto
+	if code := get("/export"); code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+	}
+	for i := 0; i < 4; i++ {
+		if code := get("/items"); code != http.StatusOK {
+			t.Fatalf("requisição %d: esperava status %d, mas recebeu %d", i+1, http.StatusOK, code)
+		}
+	}
+	if code := get("/items"); code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, code)
+	}
+}
+
+func TestValidateRoutePattern(t *testing.T) {
+	for _, pattern := range []string{"/bulk/", "GET /export/{id}", "api.example.com/v1/"} {
+		if err := ValidateRoutePattern(pattern); err != nil {
+			t.Errorf("padrão %q deveria ser válido, mas recebeu erro: %v", pattern, err)
+		}
+	}
+
+	for _, pattern := range []string{"", "GET", "/export/{id", "/users/{id}/{id}"} {
+		if err := ValidateRoutePattern(pattern); err == nil {
+			t.Errorf("padrão %q deveria ser inválido", pattern)
+		}
+	}
+}
+
+func TestValidateRoutePatterns(t *testing.T) {
+	if err := ValidateRoutePatterns("GET /export/{id}", "/bulk/", "/bulk/", "GET /export/latest"); err != nil {
+		t.Errorf("padrões deveriam ser válidos juntos, mas recebeu erro: %v", err)
+	}
+
+	// Válidos isoladamente, mas em conflito: nenhum é mais específico que o outro
+	if err := ValidateRoutePatterns("GET /a/{x}", "GET /{y}/b"); err == nil {
+		t.Error("padrões em conflito deveriam ser rejeitados")
+	}
+}
+
+func TestRateLimiterMiddleware_RouteConflict(t *testing.T) {
+	m := NewRateLimiterMiddleware(nil,
+		WithRouteCost("GET /a/{x}", 5),
+		WithRouteCost("GET /{y}/b", 7),
+		WithRoutePriority("GET /a/{x}", ratelimiter.PriorityHigh),
+	)
+
+	if m.Err() == nil {
+		t.Fatal("padrão em conflito deveria resultar em erro")
+	}
+
+	// O padrão em conflito é ignorado; o anterior continua valendo
+	if got := m.cost(httptest.NewRequest(http.MethodGet, "/a/b", nil)); got != 5 {
+		t.Errorf("custo deveria ser 5, mas recebeu %d", got)
+	}
+	if got := m.cost(httptest.NewRequest(http.MethodGet, "/c/b", nil)); got != 1 {
+		t.Errorf("custo deveria ser 1, mas recebeu %d", got)
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// CountFunc decide, depois da resposta, se a requisição deve ser contada no limite
+type CountFunc func(r *http.Request, status int) bool
+
+// WithCountOn ativa o modo de contagem por resposta: em vez de contar todas as
+// requisições, o middleware reserva o consumo antes do handler e o mantém depois dele
+// apenas se alguma das funções aceitar o status da resposta ou se o handler chamar
+// Count; caso contrário, o consumo é devolvido. É o modo indicado contra força bruta,
+// contando apenas logins com falha. Nesse modo, limites globais, cotas e o modo de
+// espera não são aplicados.
+func WithCountOn(fns ...CountFunc) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.countMode = true
+		m.countFuncs = append(m.countFuncs, fns...)
+	}
+}
+
+// StatusIn conta as respostas com um dos status informados
+func StatusIn(statuses ...int) CountFunc {
+	return func(r *http.Request, status int) bool {
+		return slices.Contains(statuses, status)
+	}
+}
+
+// StatusClass conta as respostas de uma das classes informadas (4 para 4xx, 5 para 5xx)
+func StatusClass(classes ...int) CountFunc {
+	return func(r *http.Request, status int) bool {
+		return slices.Contains(classes, status/100)
+	}
+}
+
+// countSignalKey é a chave do sinal de contagem no contexto da requisição
+type countSignalKey struct{}
+
+// Count marca a requisição para ser contada no modo de contagem por resposta,
+// independentemente do status. Um handler de login, por exemplo, chama Count quando a
+// senha está errada. Fora do modo de contagem, não tem efeito.
+func Count(r *http.Request) {
+	if counted, ok := r.Context().Value(countSignalKey{}).(*bool); ok {
+		*counted = true
+	}
+}
+
+// withCountSignal prepara a requisição para receber o sinal de Count
+func withCountSignal(r *http.Request) (*http.Request, *bool) {
+	counted := new(bool)
+	return r.WithContext(context.WithValue(r.Context(), countSignalKey{}, counted)), counted
+}
+
+// shouldCount indica se a resposta deve ser contada no limite
+func (m *RateLimiterMiddleware) shouldCount(r *http.Request, status int, signaled bool) bool {
+	if signaled {
+		return true
+	}
+	for _, fn := range m.countFuncs {
+		if fn(r, status) {
+			return true
+		}
+	}
+	return false
+}
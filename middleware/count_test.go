@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestRateLimiterMiddleware_CountOn(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(2, 5*time.Minute),
+	)
+
+	// Login falso: a senha certa retorna 200, a errada 401 e a bloqueada sinaliza com Count
+	handler := NewRateLimiterMiddleware(limiter, WithCountOn(StatusIn(http.StatusUnauthorized))).
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("password") {
+			case "right":
+				w.Write([]byte("OK"))
+			case "locked":
+				Count(r)
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+
+	login := func(password string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login?password="+password, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Logins com sucesso não são contados
+	for i := 0; i < 5; i++ {
+		if code := login("right"); code != http.StatusOK {
+			t.Fatalf("login %d deveria ter status %d, mas recebeu %d", i+1, http.StatusOK, code)
+		}
+	}
+
+	// Uma falha por status e outra sinalizada pelo handler atingem o limite
+	if code := login("wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusUnauthorized, code)
+	}
+	if code := login("locked"); code != http.StatusForbidden {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusForbidden, code)
+	}
+
+	// A chave fica bloqueada, mesmo para a senha certa
+	if code := login("right"); code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d após as falhas, mas recebeu %d", http.StatusTooManyRequests, code)
+	}
+}
+
+func TestCountFuncs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	tests := []struct {
+		name   string
+		fn     CountFunc
+		status int
+		want   bool
+	}{
+		{"status na lista", StatusIn(401, 403), 403, true},
+		{"status fora da lista", StatusIn(401, 403), 404, false},
+		{"classe 4xx", StatusClass(4), 429, true},
+		{"classe 5xx", StatusClass(4, 5), 503, true},
+		{"classe 2xx não contada", StatusClass(4, 5), 200, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(req, tt.status); got != tt.want {
+				t.Errorf("contagem do status %d deveria ser %v, mas recebeu %v", tt.status, tt.want, got)
+			}
+		})
+	}
+
+	// Fora do modo de contagem, Count não tem efeito
+	Count(req)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// PriorityFunc deriva a prioridade de uma requisição. Retorna false quando não se aplica,
+// passando a decisão para a próxima função.
+type PriorityFunc func(r *http.Request) (ratelimiter.Priority, bool)
+
+// WithRoutePriority define a prioridade das requisições que correspondem a um padrão no
+// formato do http.ServeMux. Quando mais de um padrão corresponde, vale o mais específico.
+// Padrões inválidos ou em conflito com outro padrão de WithRoutePriority são ignorados e
+// o erro é retornado por Err; valide padrões vindos da configuração com
+// ValidateRoutePatterns.
+func WithRoutePriority(pattern string, priority ratelimiter.Priority) Option {
+	return func(m *RateLimiterMiddleware) {
+		if err := m.routePriorities.set(pattern, priority); err != nil {
+			m.setErr(err)
+		}
+	}
+}
+
+// WithPriorityFunc registra funções que derivam a prioridade das requisições que não
+// correspondem a nenhum padrão de WithRoutePriority. A primeira que se aplica vence.
+func WithPriorityFunc(fns ...PriorityFunc) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.priorityFuncs = append(m.priorityFuncs, fns...)
+	}
+}
+
+// HeaderPriority lê a prioridade ("low", "normal" ou "high") de um cabeçalho. Use apenas
+// com cabeçalhos definidos por um proxy confiável, já que o cliente pode enviá-los.
+func HeaderPriority(header string) PriorityFunc {
+	return func(r *http.Request) (ratelimiter.Priority, bool) {
+		priority, err := ratelimiter.ParsePriority(r.Header.Get(header))
+		return priority, err == nil
+	}
+}
+
+// TokenPriority deriva a prioridade do token lido do cabeçalho, por exemplo a partir do
+// plano do cliente. lookup retorna false para tokens desconhecidos.
+func TokenPriority(header string, lookup func(token string) (ratelimiter.Priority, bool)) PriorityFunc {
+	return func(r *http.Request) (ratelimiter.Priority, bool) {
+		token := r.Header.Get(header)
+		if token == "" {
+			return ratelimiter.PriorityDefault, false
+		}
+		return lookup(token)
+	}
+}
+
+// priority calcula a prioridade de uma requisição: o padrão de rota correspondente, depois
+// as funções de prioridade e, por fim, PriorityDefault (o rate limiter usa PriorityNormal
+// para requisições com token e PriorityLow para as anônimas)
+func (m *RateLimiterMiddleware) priority(r *http.Request) ratelimiter.Priority {
+	if priority, ok := m.routePriorities.match(r); ok {
+		return priority
+	}
+
+	for _, fn := range m.priorityFuncs {
+		if priority, ok := fn(r); ok {
+			return priority
+		}
+	}
+
+	return ratelimiter.PriorityDefault
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+func TestRateLimiterMiddleware_Priority(t *testing.T) {
+	plans := map[string]ratelimiter.Priority{"paid": ratelimiter.PriorityHigh}
+	m := NewRateLimiterMiddleware(nil,
+		WithRoutePriority("/health", ratelimiter.PriorityHigh),
+		WithRoutePriority("GET /reports/", ratelimiter.PriorityLow),
+		WithPriorityFunc(
+			TokenPriority("API_KEY", func(token string) (ratelimiter.Priority, bool) {
+				priority, ok := plans[token]
+				return priority, ok
+			}),
+			HeaderPriority("X-Priority"),
+		),
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		header string
+		want   ratelimiter.Priority
+	}{
+		{"padrão de rota", http.MethodGet, "/health", "", "", ratelimiter.PriorityHigh},
+		{"rota vence o token", http.MethodGet, "/reports/daily", "paid", "", ratelimiter.PriorityLow},
+		{"plano do token", http.MethodGet, "/items", "paid", "", ratelimiter.PriorityHigh},
+		{"token desconhecido usa o cabeçalho", http.MethodGet, "/items", "free", "normal", ratelimiter.PriorityNormal},
+		{"cabeçalho inválido", http.MethodGet, "/items", "", "urgent", ratelimiter.PriorityDefault},
+		{"sem regra", http.MethodPost, "/reports/daily", "", "", ratelimiter.PriorityDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("API_KEY", tt.token)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Priority", tt.header)
+			}
+			if got := m.priority(req); got != tt.want {
+				t.Errorf("prioridade deveria ser %s, mas recebeu %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware_Shedding(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, 0),
+		ratelimiter.WithGlobalLimits(ratelimiter.GlobalLimit{Name: "api", Limit: 4}),
+		ratelimiter.WithSheddingPolicy(ratelimiter.SheddingPolicy{Reserved: map[ratelimiter.Priority]float64{ratelimiter.PriorityHigh: 0.5}}),
+	)
+
+	handler := NewRateLimiterMiddleware(limiter, WithRoutePriority("/checkout", ratelimiter.PriorityHigh)).
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	get := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// O tráfego anônimo usa apenas a metade não reservada da capacidade
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if code := get("/catalog"); code != want {
+			t.Errorf("requisição anônima %d deveria ter status %d, mas recebeu %d", i+1, want, code)
+		}
+	}
+
+	// A rota prioritária continua usando a capacidade reservada
+	for i := 0; i < 2; i++ {
+		if code := get("/checkout"); code != http.StatusOK {
+			t.Errorf("requisição prioritária %d deveria ter status %d, mas recebeu %d", i+1, http.StatusOK, code)
+		}
+	}
+}
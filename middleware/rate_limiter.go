@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// DefaultTokenHeader é o cabeçalho padrão de onde o token de acesso é lido
+const DefaultTokenHeader = "API_KEY"
+
+// RateLimiterMiddleware é um middleware para limitar requisições
+type RateLimiterMiddleware struct {
+	limiter         *ratelimiter.RateLimiter
+	tokenHeader     string
+	ipFunc          func(r *http.Request) string
+	trustedProxies  []netip.Prefix
+	routeCosts      routeMatcher[int]
+	costFunc        CostFunc
+	routePriorities routeMatcher[ratelimiter.Priority]
+	priorityFuncs   []PriorityFunc
+	countMode       bool
+	countFuncs      []CountFunc
+	maxWait         time.Duration
+	queue           *waitQueue
+	sleep           func(ctx context.Context, d time.Duration) error
+	// Primeiro erro de configuração das opções (veja Err)
+	err error
+}
+
+// Option configura parâmetros opcionais do middleware
+type Option func(*RateLimiterMiddleware)
+
+// WithTokenHeader define o cabeçalho de onde o token de acesso é lido
+func WithTokenHeader(header string) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.tokenHeader = header
+	}
+}
+
+// WithIPFunc substitui a extração do IP do cliente, por exemplo para usar um cabeçalho
+// específico do balanceador da aplicação
+func WithIPFunc(fn func(r *http.Request) string) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.ipFunc = fn
+	}
+}
+
+// NewRateLimiterMiddleware cria uma nova instância do middleware
+func NewRateLimiterMiddleware(limiter *ratelimiter.RateLimiter, opts ...Option) *RateLimiterMiddleware {
+	m := &RateLimiterMiddleware{
+		limiter:        limiter,
+		tokenHeader:    DefaultTokenHeader,
+		trustedProxies: DefaultTrustedProxies,
+		sleep:          sleepContext,
+	}
+	m.ipFunc = func(r *http.Request) string {
+		return ClientIP(r, m.trustedProxies)
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Err retorna o primeiro erro de configuração das opções, como um padrão de
+// WithRouteCost ou WithRoutePriority inválido ou em conflito com outro. As opções com
+// erro são ignoradas; verifique Err depois de criar o middleware.
+func (m *RateLimiterMiddleware) Err() error {
+	return m.err
+}
+
+// setErr guarda o primeiro erro de configuração
+func (m *RateLimiterMiddleware) setErr(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// Middleware retorna uma função de middleware HTTP
+func (m *RateLimiterMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Extrai o IP real do cliente (considerando cabeçalhos de proxy)
+		ip := m.ipFunc(r)
+
+		// Extrai o token de acesso do cabeçalho (se presente)
+		token := r.Header.Get(m.tokenHeader)
+
+		// Cria uma requisição para o rate limiter
+		req := &ratelimiter.LimiterRequest{
+			IP:       ip,
+			Token:    token,
+			Cost:     m.cost(r),
+			Path:     r.URL.Path,
+			Priority: m.priority(r),
+		}
+
+		// Reserva uma vaga de concorrência antes de consumir os limites, para que uma
+		// requisição sem vaga não gaste o limite por segundo nem as cotas. A vaga é
+		// liberada quando o handler termina ou quando a requisição é negada.
+		release, err := m.limiter.Acquire(r.Context(), req)
+		if err != nil {
+			var concurrencyErr *ratelimiter.ConcurrencyExceededError
+			if errors.As(err, &concurrencyErr) {
+				w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(concurrencyErr.RetryAfter.Seconds())), 10))
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer release()
+
+		// Verifica se a requisição deve ser permitida. No modo de contagem por resposta,
+		// reserva o consumo, confirmado ou devolvido depois da resposta.
+		var decision *ratelimiter.Decision
+		var reservation *ratelimiter.Reservation
+		if m.countMode {
+			decision, reservation, err = m.limiter.Reserve(r.Context(), req)
+		} else {
+			decision, err = m.limiter.Decide(r.Context(), req)
+		}
+
+		// No modo de espera, aguarda uma vaga em vez de negar imediatamente
+		if m.queue != nil && !m.countMode && err != nil {
+			decision, err = m.waitForSlot(r, req, decision, err)
+		}
+		if err != nil && !ratelimiter.IsRejected(err) {
+			// Para outros erros, retorna 500 Internal Server Error
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		// Informa o consumo das cotas de longo prazo, se configuradas
+		for name, value := range ratelimiter.QuotaHeaders(decision) {
+			w.Header().Set(name, value)
+		}
+
+		// Se o limite ou a cota foram excedidos, retorna 429 Too Many Requests
+		if err != nil {
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(decision.Reset.Seconds())), 10))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		// Com limite de banda, os corpos da requisição e da resposta são atrasados conforme
+		// o balde de bytes da chave
+		w, r = m.throttle(w, r, req)
+
+		// Sem limite adaptativo nem contagem por resposta, o status não importa
+		adaptive := m.limiter.IsAdaptive(decision.Rule)
+		if !adaptive && !m.countMode {
+			// Passa a requisição para o próximo handler
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		m.serveRecorded(next, w, r, reservation, decision.Rule, adaptive)
+	})
+}
+
+// serveRecorded chama o handler registrando a latência e o status da resposta. Com limite
+// adaptativo, informa a observação ao rate limiter; no modo de contagem por resposta,
+// confirma a reserva se o status ou o sinal de Count corresponderem e a devolve caso
+// contrário.
+func (m *RateLimiterMiddleware) serveRecorded(next http.Handler, w http.ResponseWriter, r *http.Request, reservation *ratelimiter.Reservation, rule string, adaptive bool) {
+	recorder := &statusRecorder{ResponseWriter: w}
+	counted := new(bool)
+	if m.countMode {
+		r, counted = withCountSignal(r)
+	}
+	start := time.Now()
+
+	next.ServeHTTP(recorder, r)
+
+	status := recorder.statusCode()
+	if adaptive {
+		m.limiter.Observe(rule, time.Since(start), status >= http.StatusInternalServerError)
+	}
+	if reservation == nil {
+		return
+	}
+
+	// A resposta já foi enviada e o cliente pode ter desconectado
+	ctx := context.WithoutCancel(r.Context())
+	if !m.shouldCount(r, status, *counted) {
+		reservation.Cancel(ctx)
+		return
+	}
+	if _, err := reservation.Commit(ctx); err != nil {
+		// Uma falha ao bloquear a chave afeta apenas as próximas requisições
+		log.Printf("Falha ao contar requisição %s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestLimiter cria um rate limiter em memória com um relógio falso compartilhado com o
+// armazenamento. O rate limiter é fechado ao fim do teste.
+func newTestLimiter(t *testing.T, opts ...ratelimiter.Option) (*ratelimiter.RateLimiter, *clock.Fake) {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	opts = append(opts, ratelimiter.WithClock(fakeClock))
+	limiter := ratelimiter.New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), opts...)
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter, fakeClock
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	// Cria configuração de teste
+	cfg := &ratelimiter.LimiterConfig{
+		IPLimit:        3,
+		IPBlockTime:    5 * time.Minute,
+		TokenLimit:     5,
+		TokenBlockTime: 5 * time.Minute,
+	}
+
+	// Factory para armazenamento em memória
+	storeFactory := func() (store.RateLimiterStore, error) {
+		return store.NewMemoryStore(), nil
+	}
+
+	// Cria rate limiter
+	limiter, err := ratelimiter.NewRateLimiter(storeFactory, ratelimiter.WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), ratelimiter.WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime))
+	if err != nil {
+		t.Fatalf("falha ao criar rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	// Cria middleware
+	middleware := NewRateLimiterMiddleware(limiter)
+
+	// Handler de teste simples
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	// Handler final com middleware
+	handler := middleware.Middleware(nextHandler)
+
+	// ---- Teste 1: Limite por IP ----
+	t.Run("IP Limit", func(t *testing.T) {
+		// Cria um servidor de teste
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		// Envia requisições até o limite
+		for i := 0; i < cfg.IPLimit; i++ {
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatalf("erro ao fazer requisição: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("esperava status %d, mas recebeu %d", http.StatusOK, resp.StatusCode)
+			}
+			resp.Body.Close()
+		}
+
+		// A próxima requisição deve ser bloqueada
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("erro ao fazer requisição: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, resp.StatusCode)
+		}
+	})
+
+	// ---- Teste 2: Limite por Token ----
+	t.Run("Token Limit", func(t *testing.T) {
+		// Cria um novo rate limiter para este teste
+		limiter, err := ratelimiter.NewRateLimiter(storeFactory, ratelimiter.WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), ratelimiter.WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime))
+		if err != nil {
+			t.Fatalf("falha ao criar rate limiter: %v", err)
+		}
+		defer limiter.Close()
+
+		// Cria novo middleware
+		middleware := NewRateLimiterMiddleware(limiter)
+		handler := middleware.Middleware(nextHandler)
+
+		// Cria um servidor de teste
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		// Cria um cliente HTTP para manter cookies/headers
+		client := &http.Client{}
+
+		// Envia requisições com token até o limite
+		for i := 0; i < cfg.TokenLimit; i++ {
+			req, err := http.NewRequest("GET", server.URL, nil)
+			if err != nil {
+				t.Fatalf("erro ao criar requisição: %v", err)
+			}
+
+			// Adiciona o token no header
+			req.Header.Add("API_KEY", "test-token")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("erro ao fazer requisição: %v", err)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("esperava status %d, mas recebeu %d", http.StatusOK, resp.StatusCode)
+			}
+			resp.Body.Close()
+		}
+
+		// A próxima requisição deve ser bloqueada
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatalf("erro ao criar requisição: %v", err)
+		}
+		req.Header.Add("API_KEY", "test-token")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("erro ao fazer requisição: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, resp.StatusCode)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"cliente direto ignora cabeçalhos", "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, "203.0.113.9"},
+		{"X-Forwarded-For de proxy confiável", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"X-Forwarded-For forjado pelo cliente", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"apenas proxies confiáveis", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"X-Real-IP de proxy confiável", "127.0.0.1:1234", map[string]string{"X-Real-IP": "203.0.113.8"}, "203.0.113.8"},
+		{"endereço da conexão", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			if got := ClientIP(req, DefaultTrustedProxies); got != tt.want {
+				t.Errorf("IP deveria ser %s, mas recebeu %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.10")
+	if err != nil {
+		t.Fatalf("erro ao interpretar proxies: %v", err)
+	}
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.10/32")}
+	if len(proxies) != len(want) || proxies[0] != want[0] || proxies[1] != want[1] {
+		t.Errorf("proxies deveriam ser %v, mas recebeu %v", want, proxies)
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/99"); err == nil {
+		t.Error("rede inválida deveria retornar erro")
+	}
+}
+
+func TestRateLimiterMiddleware_Quota(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithTokenLimit(100, time.Minute),
+		ratelimiter.WithQuotas(ratelimiter.TokenLimit, ratelimiter.Quota{Name: "hourly", Limit: 2, Period: ratelimiter.QuotaHourly}),
+	)
+
+	handler := NewRateLimiterMiddleware(limiter).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("API_KEY", "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := get()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+		}
+		if got := rec.Header().Get("X-Quota-Remaining"); got != []string{"1", "0"}[i] {
+			t.Errorf("X-Quota-Remaining deveria ser %s, mas recebeu %s", []string{"1", "0"}[i], got)
+		}
+	}
+
+	// Cota esgotada: 429 com Retry-After até a próxima hora
+	rec := get()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After deveria ser 3600, mas recebeu %s", got)
+	}
+}
+
+func TestRateLimiterMiddleware_Concurrency(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(2, 0),
+		ratelimiter.WithConcurrencyLimit(1, 0, time.Minute),
+	)
+
+	// O handler segura a primeira requisição até o teste liberá-la
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	handler := NewRateLimiterMiddleware(limiter).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(entered)
+			<-unblock
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	done := make(chan int)
+	go func() { done <- get("/slow").Code }()
+	<-entered
+
+	// Com a única vaga ocupada, a segunda requisição é negada com Retry-After
+	rec := get("/")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d com a vaga ocupada, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After deveria ser 1, mas recebeu %q", got)
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("esperava status %d na requisição lenta, mas recebeu %d", http.StatusOK, code)
+	}
+
+	// A vaga é liberada quando o handler termina, e a requisição negada por concorrência
+	// não consumiu o limite por segundo
+	if code := get("/").Code; code != http.StatusOK {
+		t.Errorf("esperava status %d após liberar a vaga, mas recebeu %d", http.StatusOK, code)
+	}
+	if code := get("/").Code; code != http.StatusTooManyRequests {
+		t.Errorf("esperava status %d após esgotar o limite, mas recebeu %d", http.StatusTooManyRequests, code)
+	}
+}
+
+func TestRateLimiterMiddleware_GlobalLimit(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		ratelimiter.WithIPLimit(100, 0),
+		ratelimiter.WithGlobalLimits(ratelimiter.GlobalLimit{Name: "checkout", PathPrefix: "/checkout", Limit: 2}),
+	)
+
+	handler := NewRateLimiterMiddleware(limiter).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	get := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Clientes diferentes compartilham o limite da rota
+	get("10.0.0.1", "/checkout")
+	get("10.0.0.2", "/checkout")
+	rec := get("10.0.0.3", "/checkout")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After deveria ser 1, mas recebeu %s", got)
+	}
+
+	// Outras rotas seguem apenas o limite do cliente
+	if rec := get("10.0.0.3", "/catalog"); rec.Code != http.StatusOK {
+		t.Errorf("esperava status %d, mas recebeu %d", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// statusRecorder registra o status da resposta escrita pelo handler, usado pelos limites
+// adaptativos e pelo modo de contagem por resposta
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader registra o status antes de repassá-lo
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// statusCode retorna o status da resposta, assumindo 200 se o handler não escreveu nada
+func (r *statusRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Write assume status 200 se o handler não chamou WriteHeader
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush repassa o flush para respostas em streaming
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack repassa o controle da conexão, usado em upgrades para websocket
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap permite que http.ResponseController acesse o ResponseWriter original
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
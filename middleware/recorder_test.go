@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusRecorder_Unwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	recorder := &statusRecorder{ResponseWriter: rec}
+
+	// O flush chega ao ResponseWriter original, preservando respostas em streaming
+	recorder.Write([]byte("data"))
+	if err := http.NewResponseController(recorder).Flush(); err != nil {
+		t.Fatalf("erro ao fazer flush: %v", err)
+	}
+	if !rec.Flushed {
+		t.Error("flush deveria chegar ao ResponseWriter original")
+	}
+	if recorder.status != http.StatusOK {
+		t.Errorf("status deveria ser %d, mas recebeu %d", http.StatusOK, recorder.status)
+	}
+}
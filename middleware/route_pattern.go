@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// routeMatcher associa valores a padrões de rota no formato do http.ServeMux. Quando mais
+// de um padrão corresponde, vale o mais específico, como no ServeMux.
+type routeMatcher[V any] struct {
+	mux    *http.ServeMux
+	values map[string]V
+}
+
+// set associa o valor ao padrão, substituindo o valor de um padrão já registrado. Retorna
+// um erro, sem alterar o matcher, se o padrão for inválido ou entrar em conflito com um
+// padrão já registrado.
+func (rm *routeMatcher[V]) set(pattern string, value V) error {
+	if rm.mux == nil {
+		rm.mux = http.NewServeMux()
+		rm.values = make(map[string]V)
+	}
+	// O ServeMux não aceita o mesmo padrão duas vezes; basta atualizar o valor
+	if _, ok := rm.values[pattern]; !ok {
+		if err := registerPattern(rm.mux, pattern); err != nil {
+			return err
+		}
+	}
+	rm.values[pattern] = value
+	return nil
+}
+
+// match retorna o valor do padrão mais específico que corresponde à requisição
+func (rm *routeMatcher[V]) match(r *http.Request) (V, bool) {
+	var zero V
+	if rm.mux == nil {
+		return zero, false
+	}
+	if _, pattern := rm.mux.Handler(r); pattern != "" {
+		return rm.values[pattern], true
+	}
+	return zero, false
+}
+
+// ValidateRoutePattern verifica se o padrão é aceito pelo http.ServeMux
+func ValidateRoutePattern(pattern string) error {
+	return ValidateRoutePatterns(pattern)
+}
+
+// ValidateRoutePatterns verifica se os padrões são aceitos juntos por um http.ServeMux:
+// além de válidos, não podem entrar em conflito (por exemplo, "GET /a/{x}" e
+// "GET /{y}/b", que correspondem às mesmas requisições sem que um seja mais específico).
+// Permite rejeitar a configuração de WithRouteCost e WithRoutePriority antes de
+// registrá-la; padrões repetidos são aceitos.
+func ValidateRoutePatterns(patterns ...string) error {
+	mux := http.NewServeMux()
+	seen := make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		if seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+
+		if err := registerPattern(mux, pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerPattern registra o padrão no ServeMux, convertendo em erro o panic de padrões
+// inválidos ou em conflito
+func registerPattern(mux *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("padrão de rota inválido %q: %v", pattern, r)
+		}
+	}()
+
+	mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
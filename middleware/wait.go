@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// WithWait ativa o modo de espera: quando uma chave excede o limite, a requisição aguarda
+// até haver vaga em vez de receber 429 imediatamente. A espera total é limitada por
+// maxWait e pelo prazo do contexto da requisição, e cada chave tem no máximo maxQueue
+// requisições aguardando. Use com tempo de bloqueio zero, já que uma chave bloqueada só
+// é liberada após o bloqueio, normalmente maior que maxWait.
+func WithWait(maxWait time.Duration, maxQueue int) Option {
+	return func(m *RateLimiterMiddleware) {
+		m.maxWait = maxWait
+		m.queue = newWaitQueue(maxQueue)
+	}
+}
+
+// waitQueue conta as requisições aguardando por chave
+type waitQueue struct {
+	mu      sync.Mutex
+	waiting map[string]int
+	max     int
+}
+
+// newWaitQueue cria uma fila com o limite de requisições aguardando por chave
+func newWaitQueue(max int) *waitQueue {
+	return &waitQueue{
+		waiting: make(map[string]int),
+		max:     max,
+	}
+}
+
+// enter reserva uma posição na fila da chave e retorna quantas requisições já aguardavam,
+// ou false se a fila está cheia
+func (q *waitQueue) enter(key string) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	position := q.waiting[key]
+	if position >= q.max {
+		return 0, false
+	}
+	q.waiting[key]++
+	return position, true
+}
+
+// leave libera a posição da chave na fila
+func (q *waitQueue) leave(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.waiting[key]--
+	if q.waiting[key] <= 0 {
+		delete(q.waiting, key)
+	}
+}
+
+// sleepContext aguarda a duração informada ou o cancelamento do contexto
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitForSlot aguarda o reset da chave e tenta novamente enquanto a espera couber no
+// limite. Retorna a última decisão e o erro original se não houver vaga a tempo.
+//
+// As requisições da mesma chave não acordam todas no reset: a que encontrou p requisições
+// aguardando espera também p intervalos médios entre vagas (janela / limite), de modo que
+// as novas vagas são disputadas uma de cada vez. Uma nova tentativa negada por um limite
+// global ou por uma cota não consome o limite do cliente, já que Decide devolve os
+// contadores incrementados.
+func (m *RateLimiterMiddleware) waitForSlot(r *http.Request, req *ratelimiter.LimiterRequest, decision *ratelimiter.Decision, err error) (*ratelimiter.Decision, error) {
+	if !waitable(err) {
+		return decision, err
+	}
+
+	key := req.ClientKey()
+	position, ok := m.queue.enter(key)
+	if !ok {
+		return decision, err
+	}
+	defer m.queue.leave(key)
+
+	var spread time.Duration
+	if decision.Limit > 0 {
+		spread = time.Duration(position) * decision.Window / time.Duration(decision.Limit)
+	}
+
+	ctx := r.Context()
+	budget := m.maxWait
+	for {
+		wait := decision.Reset + spread
+		if wait > budget {
+			return decision, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return decision, err
+		}
+
+		if sleepErr := m.sleep(ctx, wait); sleepErr != nil {
+			return decision, err
+		}
+		budget -= wait
+
+		decision, err = m.limiter.Decide(ctx, req)
+		if err == nil || !waitable(err) {
+			return decision, err
+		}
+	}
+}
+
+// waitable indica se vale esperar pela negação: apenas os limites por janela (do cliente
+// ou globais) justificam esperar; cotas esgotadas reiniciam muito depois
+func waitable(err error) bool {
+	var limitErr *ratelimiter.LimitExceededError
+	var globalErr *ratelimiter.GlobalLimitExceededError
+	return errors.As(err, &limitErr) || errors.As(err, &globalErr)
+}
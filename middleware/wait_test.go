@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter"
+)
+
+// newWaitHandler cria um handler em modo de espera cujo sleep apenas avança o relógio
+// falso, registrando as esperas
+func newWaitHandler(t *testing.T, blockTime, maxWait time.Duration, maxQueue int) (http.Handler, *clock.Fake, *[]time.Duration) {
+	t.Helper()
+
+	limiter, fakeClock := newTestLimiter(t,
+		ratelimiter.WithIPLimit(2, blockTime),
+	)
+
+	var waits []time.Duration
+	m := NewRateLimiterMiddleware(limiter, WithWait(maxWait, maxQueue))
+	m.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		fakeClock.Advance(d)
+		return nil
+	}
+
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	return handler, fakeClock, &waits
+}
+
+// serve envia uma requisição ao handler, opcionalmente com um contexto
+func serve(handler http.Handler, ctx context.Context) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRateLimiterMiddleware_Wait(t *testing.T) {
+	handler, fakeClock, waits := newWaitHandler(t, 0, time.Second, 10)
+
+	// A janela começa na primeira requisição
+	for i := 0; i < 2; i++ {
+		if code := serve(handler, nil); code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+		}
+		fakeClock.Advance(350 * time.Millisecond)
+	}
+
+	// A terceira aguarda o início da próxima janela em vez de receber 429
+	if code := serve(handler, nil); code != http.StatusOK {
+		t.Fatalf("esperava status %d após a espera, mas recebeu %d", http.StatusOK, code)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 300*time.Millisecond {
+		t.Errorf("deveria esperar 300ms uma vez, mas esperou %v", *waits)
+	}
+}
+
+func TestRateLimiterMiddleware_WaitBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		blockTime time.Duration
+		maxWait   time.Duration
+		maxQueue  int
+		timeout   time.Duration
+	}{
+		{"espera maior que o limite", 0, 100 * time.Millisecond, 10, 0},
+		{"fila cheia", 0, time.Second, 0, 0},
+		{"prazo do contexto", 0, time.Second, 10, 10 * time.Millisecond},
+		{"chave bloqueada", time.Minute, 5 * time.Second, 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, waits := newWaitHandler(t, tt.blockTime, tt.maxWait, tt.maxQueue)
+
+			for i := 0; i < 2; i++ {
+				if code := serve(handler, nil); code != http.StatusOK {
+					t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+				}
+			}
+
+			var ctx context.Context
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+				defer cancel()
+			}
+
+			if code := serve(handler, ctx); code != http.StatusTooManyRequests {
+				t.Errorf("esperava status %d, mas recebeu %d", http.StatusTooManyRequests, code)
+			}
+			if len(*waits) != 0 {
+				t.Errorf("não deveria esperar, mas esperou %v", *waits)
+			}
+		})
+	}
+}
+
+func TestWaitQueue(t *testing.T) {
+	q := newWaitQueue(2)
+
+	for want := 0; want < 2; want++ {
+		if position, ok := q.enter("a"); !ok || position != want {
+			t.Fatalf("deveria aceitar a requisição na posição %d, mas recebeu %d (%v)", want, position, ok)
+		}
+	}
+	if _, ok := q.enter("a"); ok {
+		t.Error("deveria rejeitar a terceira requisição da mesma chave")
+	}
+	if position, ok := q.enter("b"); !ok || position != 0 {
+		t.Error("outra chave deveria ter sua própria fila")
+	}
+
+	q.leave("a")
+	if position, ok := q.enter("a"); !ok || position != 1 {
+		t.Errorf("deveria aceitar requisição na posição 1 após uma saída da fila, mas recebeu %d (%v)", position, ok)
+	}
+}
+
+func TestRateLimiterMiddleware_WaitSpread(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		ratelimiter.WithIPLimit(2, 0),
+	)
+
+	var waits []time.Duration
+	m := NewRateLimiterMiddleware(limiter, WithWait(2*time.Second, 10))
+	m.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		fakeClock.Advance(d)
+		return nil
+	}
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		if code := serve(handler, nil); code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+		}
+	}
+
+	// Com uma requisição já aguardando, a próxima acorda meio segundo (janela / limite) depois do reset
+	m.queue.enter("ip:192.0.2.1")
+	defer m.queue.leave("ip:192.0.2.1")
+
+	if code := serve(handler, nil); code != http.StatusOK {
+		t.Fatalf("esperava status %d após a espera, mas recebeu %d", http.StatusOK, code)
+	}
+	if len(waits) != 1 || waits[0] != 1500*time.Millisecond {
+		t.Errorf("deveria esperar 1.5s uma vez, mas esperou %v", waits)
+	}
+}
+
+func TestRateLimiterMiddleware_WaitGlobalLimitNotRecharged(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		ratelimiter.WithIPLimit(3, 0),
+		ratelimiter.WithGlobalLimits(ratelimiter.GlobalLimit{Name: "all", Limit: 1, Window: 200 * time.Millisecond}),
+	)
+
+	var waits []time.Duration
+	m := NewRateLimiterMiddleware(limiter, WithWait(time.Second, 10))
+	m.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		fakeClock.Advance(d)
+		return nil
+	}
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// A segunda requisição aguarda o limite global; a negação não deve consumir o limite do IP
+	for i := 0; i < 2; i++ {
+		if code := serve(handler, nil); code != http.StatusOK {
+			t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+		}
+	}
+
+	// Com duas requisições contadas para o IP, a terceira passa sem esperar
+	fakeClock.Advance(200 * time.Millisecond)
+	if code := serve(handler, nil); code != http.StatusOK {
+		t.Fatalf("esperava status %d, mas recebeu %d", http.StatusOK, code)
+	}
+	if len(waits) != 1 || waits[0] != 200*time.Millisecond {
+		t.Errorf("deveria esperar 200ms uma vez, mas esperou %v", waits)
+	}
+}
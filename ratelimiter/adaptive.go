@@ -0,0 +1,209 @@
+package ratelimiter
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Valores usados quando os campos de AdaptiveConfig são zero
+const (
+	DefaultAdaptiveIncrease       = 1
+	DefaultAdaptiveDecreaseFactor = 0.5
+	DefaultAdaptiveMaxErrorRate   = 0.05
+	DefaultAdaptiveInterval       = time.Second
+)
+
+// AdaptiveConfig configura o ajuste automático do limite de uma regra com AIMD
+// (aumento aditivo, redução multiplicativa). A cada intervalo, se a latência média
+// do backend passou de TargetLatency ou a taxa de erros passou de MaxErrorRate, o
+// limite é multiplicado por DecreaseFactor; caso contrário, cresce Increase unidades.
+// O limite nunca sai de [Floor, Ceiling].
+type AdaptiveConfig struct {
+	// Menor limite permitido (mínimo 1)
+	Floor int
+	// Maior limite permitido
+	Ceiling int
+	// Quanto o limite cresce por intervalo saudável (0 usa DefaultAdaptiveIncrease)
+	Increase int
+	// Fator aplicado ao limite em um intervalo degradado (0 usa DefaultAdaptiveDecreaseFactor)
+	DecreaseFactor float64
+	// Latência média acima da qual o backend é considerado degradado (0 ignora a latência)
+	TargetLatency time.Duration
+	// Fração de respostas com erro acima da qual o backend é considerado degradado
+	// (0 usa DefaultAdaptiveMaxErrorRate)
+	MaxErrorRate float64
+	// Intervalo entre ajustes (0 usa DefaultAdaptiveInterval)
+	Interval time.Duration
+}
+
+// AdaptiveLimit é o estado atual do limite adaptativo de uma regra
+type AdaptiveLimit struct {
+	// Nome da regra
+	Rule string
+	// Limite calculado em vigor
+	Limit int
+	// Menor e maior limite permitidos
+	Floor   int
+	Ceiling int
+}
+
+// adaptiveLimit mantém o limite calculado de uma regra e as observações do intervalo atual
+type adaptiveLimit struct {
+	config AdaptiveConfig
+
+	mu          sync.Mutex
+	limit       int
+	base        int
+	windowStart time.Time
+	samples     int
+	failures    int
+	latency     time.Duration
+}
+
+// newAdaptiveLimit aplica os valores padrão e normaliza os limites da configuração
+func newAdaptiveLimit(cfg AdaptiveConfig) *adaptiveLimit {
+	if cfg.Floor < 1 {
+		cfg.Floor = 1
+	}
+	if cfg.Ceiling < cfg.Floor {
+		cfg.Ceiling = cfg.Floor
+	}
+	if cfg.Increase <= 0 {
+		cfg.Increase = DefaultAdaptiveIncrease
+	}
+	if cfg.DecreaseFactor <= 0 || cfg.DecreaseFactor >= 1 {
+		cfg.DecreaseFactor = DefaultAdaptiveDecreaseFactor
+	}
+	if cfg.MaxErrorRate <= 0 {
+		cfg.MaxErrorRate = DefaultAdaptiveMaxErrorRate
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultAdaptiveInterval
+	}
+
+	return &adaptiveLimit{config: cfg}
+}
+
+// current retorna o limite em vigor. Na primeira consulta, e sempre que o limite base da
+// regra muda (por exemplo, na troca de período de um agendamento), o controlador recomeça
+// do novo limite base, ajustado ao intervalo [Floor, Ceiling].
+func (a *adaptiveLimit) current(base int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rebase(base)
+	return a.limit
+}
+
+// rebase recomeça o controlador do limite base informado se ele mudou. Deve ser chamado
+// com o lock adquirido.
+func (a *adaptiveLimit) rebase(base int) {
+	if a.limit != 0 && a.base == base {
+		return
+	}
+	a.base = base
+	a.limit = a.clamp(base)
+}
+
+// observe registra uma resposta do backend e, se o intervalo terminou, ajusta o limite
+// com base nas observações acumuladas
+func (a *adaptiveLimit) observe(now time.Time, base int, latency time.Duration, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rebase(base)
+	if a.windowStart.IsZero() {
+		a.windowStart = now
+	}
+
+	if now.Sub(a.windowStart) >= a.config.Interval {
+		a.adjust()
+		a.windowStart = now
+		a.samples, a.failures, a.latency = 0, 0, 0
+	}
+
+	a.samples++
+	a.latency += latency
+	if failed {
+		a.failures++
+	}
+}
+
+// adjust aplica um passo de AIMD. Deve ser chamado com o lock adquirido.
+func (a *adaptiveLimit) adjust() {
+	if a.samples == 0 {
+		return
+	}
+
+	errorRate := float64(a.failures) / float64(a.samples)
+	meanLatency := a.latency / time.Duration(a.samples)
+	degraded := errorRate > a.config.MaxErrorRate ||
+		(a.config.TargetLatency > 0 && meanLatency > a.config.TargetLatency)
+
+	if degraded {
+		a.limit = a.clamp(int(math.Floor(float64(a.limit) * a.config.DecreaseFactor)))
+		return
+	}
+	a.limit = a.clamp(a.limit + a.config.Increase)
+}
+
+// clamp mantém o limite dentro de [Floor, Ceiling]
+func (a *adaptiveLimit) clamp(limit int) int {
+	return min(max(limit, a.config.Floor), a.config.Ceiling)
+}
+
+// Observe informa ao limite adaptativo da regra a latência e o resultado de uma resposta
+// do backend. Regras sem limite adaptativo ignoram a observação.
+func (rl *RateLimiter) Observe(rule string, latency time.Duration, failed bool) {
+	adaptive, ok := rl.adaptive[rule]
+	if !ok {
+		return
+	}
+
+	base, ok := rl.rule(rule)
+	if !ok {
+		return
+	}
+	base, _ = rl.applySchedule(base)
+	adaptive.observe(rl.clock.Now(), base.Limit, latency, failed)
+}
+
+// IsAdaptive indica se a regra tem limite adaptativo
+func (rl *RateLimiter) IsAdaptive(rule string) bool {
+	_, ok := rl.adaptive[rule]
+	return ok
+}
+
+// AdaptiveLimits retorna o limite calculado em vigor de cada regra adaptativa, ordenado
+// pelo nome da regra
+func (rl *RateLimiter) AdaptiveLimits() []AdaptiveLimit {
+	limits := make([]AdaptiveLimit, 0, len(rl.adaptive))
+	for name, adaptive := range rl.adaptive {
+		base, ok := rl.rule(name)
+		if !ok {
+			continue
+		}
+		base, _ = rl.applySchedule(base)
+		limits = append(limits, AdaptiveLimit{
+			Rule:    name,
+			Limit:   adaptive.current(base.Limit),
+			Floor:   adaptive.config.Floor,
+			Ceiling: adaptive.config.Ceiling,
+		})
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].Rule < limits[j].Rule
+	})
+
+	return limits
+}
+
+// applyAdaptive substitui o limite estático da regra pelo limite adaptativo em vigor
+func (rl *RateLimiter) applyAdaptive(rule Rule) Rule {
+	if adaptive, ok := rl.adaptive[rule.Name]; ok {
+		rule.Limit = adaptive.current(rule.Limit)
+	}
+	return rule
+}
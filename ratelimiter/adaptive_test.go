@@ -0,0 +1,123 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestRateLimiter_AdaptiveLimit(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		WithIPLimit(10, 0),
+		WithAdaptiveLimit(IPRuleName, AdaptiveConfig{
+			Floor:         4,
+			Ceiling:       12,
+			TargetLatency: 100 * time.Millisecond,
+			Interval:      time.Second,
+		}),
+	)
+
+	// observeInterval registra uma resposta e fecha o intervalo na próxima observação
+	observeInterval := func(latency time.Duration, failed bool) int {
+		limiter.Observe(IPRuleName, latency, failed)
+		fakeClock.Advance(time.Second)
+		limiter.Observe(IPRuleName, 0, false)
+		return limiter.AdaptiveLimits()[0].Limit
+	}
+
+	// O limite parte do valor estático da regra
+	if got := limiter.AdaptiveLimits()[0].Limit; got != 10 {
+		t.Fatalf("limite inicial deveria ser 10, mas recebeu %d", got)
+	}
+
+	// Intervalos saudáveis aumentam o limite de 1 em 1 até o teto
+	if got := observeInterval(10*time.Millisecond, false); got != 11 {
+		t.Errorf("limite após intervalo saudável deveria ser 11, mas recebeu %d", got)
+	}
+	observeInterval(10*time.Millisecond, false)
+	if got := observeInterval(10*time.Millisecond, false); got != 12 {
+		t.Errorf("limite não deveria passar do teto 12, mas recebeu %d", got)
+	}
+
+	// Erros reduzem o limite pela metade
+	if got := observeInterval(10*time.Millisecond, true); got != 6 {
+		t.Errorf("limite após erros deveria ser 6, mas recebeu %d", got)
+	}
+
+	// Latência acima do alvo também reduz, respeitando o piso
+	if got := observeInterval(500*time.Millisecond, false); got != 4 {
+		t.Errorf("limite não deveria ficar abaixo do piso 4, mas recebeu %d", got)
+	}
+
+	// O limite calculado é o aplicado às requisições
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{IP: "192.168.1.1"}); err != nil {
+			t.Fatalf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	decision, err := limiter.Decide(ctx, &LimiterRequest{IP: "192.168.1.1"})
+	if !IsRejected(err) {
+		t.Errorf("deveria negar a quinta requisição com limite adaptativo 4, mas recebeu: %v", err)
+	}
+	if decision.Limit != 4 {
+		t.Errorf("limite da decisão deveria ser 4, mas recebeu %d", decision.Limit)
+	}
+}
+
+func TestRateLimiter_AdaptiveFollowsSchedule(t *testing.T) {
+	// 2025-01-01 às 12h, fora do período noturno
+	limiter, fakeClock := newTestLimiter(t,
+		WithIPLimit(10, 0),
+		WithSchedule(IPRuleName, &Schedule{Periods: []SchedulePeriod{
+			{Name: "night", Start: 22 * time.Hour, End: 6 * time.Hour, Limit: 4},
+		}}),
+		WithAdaptiveLimit(IPRuleName, AdaptiveConfig{Floor: 1, Ceiling: 20, Interval: time.Second}),
+	)
+
+	limiter.Observe(IPRuleName, 0, false)
+	fakeClock.Advance(time.Second)
+	limiter.Observe(IPRuleName, 0, false)
+	if got := limiter.AdaptiveLimits()[0].Limit; got != 11 {
+		t.Fatalf("limite após intervalo saudável deveria ser 11, mas recebeu %d", got)
+	}
+
+	// Na troca de período, o controlador recomeça do limite do período
+	fakeClock.Advance(11 * time.Hour)
+	decision, err := limiter.Decide(context.Background(), &LimiterRequest{IP: "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("erro ao decidir: %v", err)
+	}
+	if decision.Limit != 4 || decision.Schedule != "night" {
+		t.Errorf("deveria usar o limite do período noturno, mas recebeu limite %d e período %q", decision.Limit, decision.Schedule)
+	}
+
+	// E volta ao limite estático quando o período termina
+	fakeClock.Advance(8 * time.Hour)
+	if got := limiter.AdaptiveLimits()[0].Limit; got != 10 {
+		t.Errorf("limite fora do período deveria voltar a 10, mas recebeu %d", got)
+	}
+}
+
+func TestRateLimiter_AdaptiveIgnoresOtherRules(t *testing.T) {
+	limiter := New(store.NewMemoryStore(), WithAdaptiveLimit(TokenRuleName, AdaptiveConfig{Floor: 1, Ceiling: 5}))
+	defer limiter.Close()
+
+	if limiter.IsAdaptive(IPRuleName) {
+		t.Error("regra de IP não deveria ser adaptativa")
+	}
+	if !limiter.IsAdaptive(TokenRuleName) {
+		t.Error("regra de token deveria ser adaptativa")
+	}
+
+	// Observações de regras não adaptativas são ignoradas
+	limiter.Observe(IPRuleName, time.Second, true)
+
+	// O limite estático acima do teto é ajustado ao intervalo [Floor, Ceiling]
+	limits := limiter.AdaptiveLimits()
+	if len(limits) != 1 || limits[0].Rule != TokenRuleName || limits[0].Limit != 5 {
+		t.Errorf("limites adaptativos deveriam ser [token=5], mas recebeu %+v", limits)
+	}
+}
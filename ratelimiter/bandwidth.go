@@ -0,0 +1,72 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// ErrBandwidthUnsupported é retornado quando o limite de banda está configurado, mas o
+// armazenamento não implementa store.TokenBucketStore
+var ErrBandwidthUnsupported = errors.New("armazenamento não suporta limite de banda")
+
+// BandwidthLimit limita os bytes por segundo de uma chave com um balde de fichas em bytes
+type BandwidthLimit struct {
+	// Bytes por segundo
+	Rate int
+	// Rajada máxima em bytes (0 equivale a Rate, ou seja, um segundo de banda)
+	Burst int
+}
+
+// burst retorna a rajada do limite, tratando zero como um segundo de banda
+func (b BandwidthLimit) burst() int {
+	if b.Burst <= 0 {
+		return b.Rate
+	}
+	return b.Burst
+}
+
+// BandwidthChunk retorna o maior bloco de bytes que pode ser reservado de uma vez para a
+// requisição (a rajada do limite), ou 0 se a requisição não tem limite de banda
+func (rl *RateLimiter) BandwidthChunk(req *LimiterRequest) int {
+	limit, _, ok := rl.bandwidthLimit(req)
+	if !ok {
+		return 0
+	}
+	return limit.burst()
+}
+
+// ReserveBandwidth consome n bytes da banda da chave da requisição e retorna quanto tempo
+// o chamador deve esperar antes de transferi-los. O IP e o token têm baldes separados,
+// e o token tem prioridade quando presente. Sem limite de banda, retorna 0.
+func (rl *RateLimiter) ReserveBandwidth(ctx context.Context, req *LimiterRequest, n int) (time.Duration, error) {
+	limit, key, ok := rl.bandwidthLimit(req)
+	if !ok || n <= 0 {
+		return 0, nil
+	}
+
+	bucketStore, ok := rl.store.(store.TokenBucketStore)
+	if !ok {
+		return 0, ErrBandwidthUnsupported
+	}
+
+	wait, err := bucketStore.Reserve(ctx, key, n, float64(limit.Rate), limit.burst())
+	if err != nil {
+		return 0, fmt.Errorf("erro ao reservar banda: %w", err)
+	}
+	return wait, nil
+}
+
+// bandwidthLimit retorna o limite de banda e a chave do balde da requisição
+func (rl *RateLimiter) bandwidthLimit(req *LimiterRequest) (BandwidthLimit, string, bool) {
+	limitType, key, _ := rl.clientRule(req)
+
+	limit, ok := rl.bandwidth[limitType]
+	if !ok || limit.Rate <= 0 {
+		return BandwidthLimit{}, "", false
+	}
+	return limit, "bandwidth:" + key, true
+}
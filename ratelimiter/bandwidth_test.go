@@ -0,0 +1,54 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestRateLimiter_ReserveBandwidth(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		WithBandwidthLimit(TokenLimit, BandwidthLimit{Rate: 1024}),
+	)
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1", Token: "abc"}
+
+	// Sem rajada definida, o balde guarda um segundo de banda
+	if chunk := limiter.BandwidthChunk(req); chunk != 1024 {
+		t.Errorf("bloco deveria ser 1024, mas recebeu %d", chunk)
+	}
+	if wait, err := limiter.ReserveBandwidth(ctx, req, 1024); err != nil || wait != 0 {
+		t.Fatalf("reserva dentro da rajada não deveria esperar (espera: %v, erro: %v)", wait, err)
+	}
+
+	// Os próximos 512 bytes esperam meio segundo
+	wait, err := limiter.ReserveBandwidth(ctx, req, 512)
+	if err != nil {
+		t.Fatalf("erro ao reservar banda: %v", err)
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("espera deveria ser 500ms, mas recebeu %v", wait)
+	}
+
+	// Requisições sem token usam o limite de IP, que não foi configurado
+	if chunk := limiter.BandwidthChunk(&LimiterRequest{IP: "192.168.1.1"}); chunk != 0 {
+		t.Errorf("requisição sem limite de banda deveria ter bloco 0, mas recebeu %d", chunk)
+	}
+	if wait, err := limiter.ReserveBandwidth(ctx, &LimiterRequest{IP: "192.168.1.1"}, 1<<20); err != nil || wait != 0 {
+		t.Errorf("requisição sem limite de banda não deveria esperar (espera: %v, erro: %v)", wait, err)
+	}
+}
+
+func TestRateLimiter_ReserveBandwidthUnsupported(t *testing.T) {
+	unsupported := struct{ store.RateLimiterStore }{store.NewMemoryStore()}
+	limiter := New(unsupported, WithBandwidthLimit(IPLimit, BandwidthLimit{Rate: 1024}))
+	defer limiter.Close()
+
+	if _, err := limiter.ReserveBandwidth(context.Background(), &LimiterRequest{IP: "192.168.1.1"}, 10); !errors.Is(err, ErrBandwidthUnsupported) {
+		t.Errorf("erro deveria ser ErrBandwidthUnsupported, mas recebeu: %v", err)
+	}
+}
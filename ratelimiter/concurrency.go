@@ -0,0 +1,140 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+const (
+	// DefaultConcurrencyLeaseTTL é o tempo padrão de expiração de uma vaga não liberada
+	DefaultConcurrencyLeaseTTL = time.Minute
+
+	// ConcurrencyRetryAfter é o tempo sugerido ao cliente para tentar novamente quando
+	// não há vaga de concorrência. As vagas são liberadas quando as requisições em
+	// andamento terminam, então não há um instante exato de reset.
+	ConcurrencyRetryAfter = time.Second
+
+	// releaseTimeout limita o tempo gasto liberando ou renovando uma vaga no armazenamento
+	releaseTimeout = 5 * time.Second
+)
+
+// ErrConcurrencyUnsupported é retornado quando o limite de concorrência está configurado,
+// mas o armazenamento não implementa store.ConcurrencyStore
+var ErrConcurrencyUnsupported = errors.New("armazenamento não suporta limite de concorrência")
+
+// ConcurrencyExceededError é retornado quando a chave já tem o máximo de requisições
+// simultâneas em andamento
+type ConcurrencyExceededError struct {
+	// Tipo de limitação (IP ou token) da chave
+	Type LimitType
+	// Número máximo de requisições simultâneas
+	Limit int
+	// Tempo sugerido até tentar novamente
+	RetryAfter time.Duration
+	// Mensagem de erro
+	Message string
+}
+
+// Error implementa a interface error
+func (e *ConcurrencyExceededError) Error() string {
+	return e.Message
+}
+
+// NewConcurrencyExceededError cria um novo erro de concorrência excedida
+func NewConcurrencyExceededError(limitType LimitType, limit int) *ConcurrencyExceededError {
+	return &ConcurrencyExceededError{
+		Type:       limitType,
+		Limit:      limit,
+		RetryAfter: ConcurrencyRetryAfter,
+		Message:    "you have reached the maximum number of concurrent requests allowed",
+	}
+}
+
+// Acquire reserva uma vaga de concorrência para a requisição. A função retornada libera
+// a vaga e deve ser chamada quando a requisição terminar. Enquanto a requisição está em
+// andamento, a reserva é renovada a cada metade do TTL, então requisições mais longas que
+// o TTL mantêm a vaga; se a instância parar sem liberá-la, a vaga expira após o TTL. Sem
+// limite de concorrência para o tipo da chave, retorna uma função vazia. Quando não há
+// vaga, retorna um *ConcurrencyExceededError.
+func (rl *RateLimiter) Acquire(ctx context.Context, req *LimiterRequest) (func(), error) {
+	// Se um token foi fornecido, ele tem prioridade sobre o IP
+	limitType, key, _ := rl.clientRule(req)
+	key = "concurrency:" + key
+	limit := rl.config.IPConcurrency
+	if limitType == TokenLimit {
+		limit = rl.config.TokenConcurrency
+	}
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	concurrencyStore, ok := rl.store.(store.ConcurrencyStore)
+	if !ok {
+		return nil, ErrConcurrencyUnsupported
+	}
+
+	lease, ok, err := concurrencyStore.Acquire(ctx, key, limit, rl.config.ConcurrencyLeaseTTL)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar vaga de concorrência: %w", err)
+	}
+	if !ok {
+		return nil, NewConcurrencyExceededError(limitType, limit)
+	}
+
+	done := make(chan struct{})
+	go renewLease(concurrencyStore, key, lease, rl.config.ConcurrencyLeaseTTL, done)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(done)
+
+			// A requisição pode ter sido cancelada; a liberação usa um contexto próprio
+			ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+			defer cancel()
+			concurrencyStore.Release(ctx, key, lease)
+		})
+	}
+	return release, nil
+}
+
+// renewLease renova a reserva a cada metade do TTL até done ser fechado ou a reserva não
+// poder mais ser renovada. Um erro do armazenamento (uma falha de rede passageira, por
+// exemplo) não encerra a renovação: a tentativa é repetida a cada oitavo do TTL enquanto
+// a reserva ainda não expirou.
+func renewLease(concurrencyStore store.ConcurrencyStore, key, lease string, ttl time.Duration, done <-chan struct{}) {
+	timer := time.NewTimer(ttl / 2)
+	defer timer.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+
+		attempt := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		ok, err := concurrencyStore.Renew(ctx, key, lease, ttl)
+		cancel()
+		switch {
+		case err != nil:
+			// A reserva já expirou no armazenamento: não há o que renovar
+			if time.Since(renewed) >= ttl {
+				return
+			}
+			timer.Reset(ttl / 8)
+		case !ok:
+			return
+		default:
+			renewed = attempt
+			timer.Reset(ttl / 2)
+		}
+	}
+}
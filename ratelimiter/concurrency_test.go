@@ -0,0 +1,164 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestRateLimiter_Acquire(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t, WithConcurrencyLimit(1, 2, time.Minute))
+
+	ctx := context.Background()
+
+	// O token tem duas vagas
+	releaseFirst, err := limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1", Token: "abc"})
+	if err != nil {
+		t.Fatalf("deveria reservar a primeira vaga do token, mas recebeu erro: %v", err)
+	}
+	if _, err := limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1", Token: "abc"}); err != nil {
+		t.Fatalf("deveria reservar a segunda vaga do token, mas recebeu erro: %v", err)
+	}
+
+	_, err = limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1", Token: "abc"})
+	var concurrencyErr *ConcurrencyExceededError
+	if !errors.As(err, &concurrencyErr) {
+		t.Fatalf("erro deveria ser ConcurrencyExceededError, mas recebeu: %v", err)
+	}
+	if concurrencyErr.Type != TokenLimit || concurrencyErr.Limit != 2 {
+		t.Errorf("erro deveria indicar token com limite 2, mas recebeu tipo %v e limite %d", concurrencyErr.Type, concurrencyErr.Limit)
+	}
+	if !IsRejected(err) {
+		t.Error("IsRejected deveria reconhecer ConcurrencyExceededError")
+	}
+
+	// Liberar uma vaga permite outra requisição
+	releaseFirst()
+	if _, err := limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1", Token: "abc"}); err != nil {
+		t.Errorf("deveria reservar a vaga liberada, mas recebeu erro: %v", err)
+	}
+
+	// O IP tem seu próprio limite, independente das vagas do token
+	if _, err := limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1"}); err != nil {
+		t.Fatalf("deveria reservar a vaga do IP, mas recebeu erro: %v", err)
+	}
+	if _, err := limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1"}); !IsRejected(err) {
+		t.Errorf("deveria negar a segunda vaga do IP, mas recebeu: %v", err)
+	}
+
+	// Vagas não liberadas expiram após o TTL
+	fakeClock.Advance(time.Minute)
+	if _, err := limiter.Acquire(ctx, &LimiterRequest{IP: "192.168.1.1"}); err != nil {
+		t.Errorf("deveria reservar a vaga expirada, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_AcquireRenewsLease(t *testing.T) {
+	ttl := 100 * time.Millisecond
+	limiter, fakeClock := newTestLimiter(t, WithConcurrencyLimit(1, 1, ttl))
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	release, err := limiter.Acquire(ctx, req)
+	if err != nil {
+		t.Fatalf("deveria reservar a vaga, mas recebeu erro: %v", err)
+	}
+
+	// Enquanto a requisição está em andamento, a reserva é renovada a cada metade do TTL
+	fakeClock.Advance(80 * time.Millisecond)
+	time.Sleep(3 * ttl / 2)
+	fakeClock.Advance(80 * time.Millisecond)
+
+	_, err = limiter.Acquire(ctx, req)
+	var concurrencyErr *ConcurrencyExceededError
+	if !errors.As(err, &concurrencyErr) {
+		t.Fatalf("a vaga renovada não deveria expirar, mas recebeu: %v", err)
+	}
+	if concurrencyErr.RetryAfter != ConcurrencyRetryAfter {
+		t.Errorf("RetryAfter deveria ser %v, mas recebeu %v", ConcurrencyRetryAfter, concurrencyErr.RetryAfter)
+	}
+
+	release()
+	release()
+	if _, err := limiter.Acquire(ctx, req); err != nil {
+		t.Errorf("deveria reservar a vaga liberada, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_AcquireDisabled(t *testing.T) {
+	// Sem limite de concorrência, qualquer armazenamento é aceito
+	unsupported := struct{ store.RateLimiterStore }{store.NewMemoryStore()}
+	limiter := New(unsupported)
+	defer limiter.Close()
+
+	release, err := limiter.Acquire(context.Background(), &LimiterRequest{IP: "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("não deveria retornar erro sem limite de concorrência: %v", err)
+	}
+	release()
+
+	// Com limite, o armazenamento precisa implementar store.ConcurrencyStore
+	limiter = New(unsupported, WithConcurrencyLimit(1, 1, time.Minute))
+	if _, err := limiter.Acquire(context.Background(), &LimiterRequest{IP: "192.168.1.1"}); !errors.Is(err, ErrConcurrencyUnsupported) {
+		t.Errorf("erro deveria ser ErrConcurrencyUnsupported, mas recebeu: %v", err)
+	}
+}
+
+// flakyConcurrencyStore falha as primeiras renovações e registra quantas foram tentadas
+type flakyConcurrencyStore struct {
+	store.ConcurrencyStore
+	failures int
+	expired  bool
+	renews   chan struct{}
+}
+
+func (s *flakyConcurrencyStore) Renew(ctx context.Context, key, lease string, ttl time.Duration) (bool, error) {
+	s.renews <- struct{}{}
+	if s.failures > 0 {
+		s.failures--
+		return false, errors.New("conexão recusada")
+	}
+	return !s.expired, nil
+}
+
+func TestRenewLease_RetriesAfterError(t *testing.T) {
+	flaky := &flakyConcurrencyStore{failures: 2, renews: make(chan struct{}, 16)}
+	done := make(chan struct{})
+	defer close(done)
+
+	go renewLease(flaky, "concurrency:ip:192.168.1.1", "lease", 80*time.Millisecond, done)
+
+	// Duas falhas seguidas de renovações bem-sucedidas: a renovação continua
+	for i := 0; i < 4; i++ {
+		select {
+		case <-flaky.renews:
+		case <-time.After(time.Second):
+			t.Fatalf("renovação %d deveria ter sido tentada depois dos erros", i+1)
+		}
+	}
+}
+
+func TestRenewLease_StopsWhenExpired(t *testing.T) {
+	flaky := &flakyConcurrencyStore{expired: true, renews: make(chan struct{}, 16)}
+	done := make(chan struct{})
+	defer close(done)
+
+	go renewLease(flaky, "concurrency:ip:192.168.1.1", "lease", 40*time.Millisecond, done)
+
+	select {
+	case <-flaky.renews:
+	case <-time.After(time.Second):
+		t.Fatal("a primeira renovação deveria ter sido tentada")
+	}
+
+	// Uma reserva que não existe mais não é renovada de novo
+	select {
+	case <-flaky.renews:
+		t.Error("a renovação deveria parar quando a reserva expirou")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,117 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reservation é o consumo de uma requisição reservado por Reserve, confirmado com Commit
+// quando a resposta deve ser contada ou devolvido com Cancel
+type Reservation struct {
+	rl       *RateLimiter
+	key      string
+	rule     Rule
+	charge   charge
+	decision Decision
+}
+
+// Reserve consome o custo da requisição no limite do cliente antes de atendê-la, para
+// contar apenas algumas respostas (por exemplo, logins com falha): depois da resposta,
+// Commit mantém o consumo e Cancel o devolve. O incremento é atômico, então requisições
+// simultâneas da mesma chave não passam do limite; enquanto estão em andamento, as
+// reservas ocupam o limite. A requisição é negada enquanto a chave está bloqueada ou se o
+// consumo passaria do limite da janela; nesse caso, retorna a decisão e um
+// *LimitExceededError, sem reserva.
+func (rl *RateLimiter) Reserve(ctx context.Context, req *LimiterRequest) (*Decision, *Reservation, error) {
+	cost, err := requestCost(req.Cost)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limitType, key, rule := rl.clientRule(req)
+	rule, period := rl.effectiveRule(rule)
+	decision := &Decision{
+		Rule:     rule.Name,
+		Key:      key,
+		Limit:    rule.Limit,
+		Schedule: period,
+		Window:   rule.window(),
+	}
+
+	if rule.BlockTime > 0 {
+		ttl, err := rl.store.BlockTTL(ctx, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao verificar bloqueio: %w", err)
+		}
+		if ttl > 0 {
+			decision.Reset = ttl
+			return decision, nil, decisionError(decision, limitType)
+		}
+	}
+
+	windowCharge := rl.windowCharge(key, rule, cost)
+	count, reset, err := rl.incrementWindow(ctx, key, rule, cost)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao reservar requisição: %w", err)
+	}
+
+	decision.Reset = reset
+	if count > rule.Limit {
+		rl.refund(ctx, []charge{windowCharge})
+		return decision, nil, decisionError(decision, limitType)
+	}
+
+	decision.Allowed = true
+	decision.Remaining = rule.Limit - count
+	return decision, &Reservation{
+		rl:       rl,
+		key:      key,
+		rule:     rule,
+		charge:   windowCharge,
+		decision: *decision,
+	}, nil
+}
+
+// Commit mantém o consumo da reserva, contando a requisição. Se as reservas confirmadas
+// na janela atingiram o limite, a chave é bloqueada pelo tempo de bloqueio da regra (ou,
+// sem tempo de bloqueio, Reserve nega as requisições até o fim da janela). A decisão
+// retornada indica se novas requisições serão permitidas.
+func (r *Reservation) Commit(ctx context.Context) (*Decision, error) {
+	// O contador da janela inclui as reservas em andamento, que ainda podem ser
+	// canceladas, e a contagem vista por Reserve pode estar desatualizada. O bloqueio
+	// depende apenas das confirmações, somadas atomicamente em um contador próprio que
+	// expira junto com a janela.
+	_, reset, err := r.rl.windowCount(ctx, r.key, r.rule)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter janela: %w", err)
+	}
+	committedKey := "committed:" + r.charge.key
+	count, err := r.rl.store.IncrementRequestCountBy(ctx, committedKey, r.charge.cost, reset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao confirmar reserva: %w", err)
+	}
+
+	decision := r.decision
+	decision.Remaining = max(r.rule.Limit-count, 0)
+	decision.Reset = reset
+	if count < r.rule.Limit {
+		return &decision, nil
+	}
+
+	decision.Allowed = false
+	if r.rule.BlockTime > 0 {
+		if err := r.rl.store.Block(ctx, r.key, r.rule.BlockTime); err != nil {
+			return nil, fmt.Errorf("erro ao bloquear chave: %w", err)
+		}
+		// Block reinicia o contador da janela; as confirmações recomeçam com ele
+		r.rl.store.IncrementRequestCountBy(ctx, committedKey, -count, reset)
+		decision.Reset = r.rule.BlockTime
+		r.rl.emitBlocked(r.key, r.rule, count)
+	}
+	return &decision, nil
+}
+
+// Cancel devolve o consumo da reserva, para uma requisição que não deve ser contada
+func (r *Reservation) Cancel(ctx context.Context) {
+	r.rl.refund(ctx, []charge{r.charge})
+}
@@ -0,0 +1,206 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_ReserveCommitAndCancel(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t, WithIPLimit(3, time.Minute))
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	// Reservas canceladas não consomem o limite
+	for i := 0; i < 5; i++ {
+		decision, reservation, err := limiter.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve deveria permitir, mas recebeu erro: %v", err)
+		}
+		if decision.Remaining != 2 {
+			t.Errorf("restante deveria ser 2 durante a reserva, mas recebeu %d", decision.Remaining)
+		}
+		reservation.Cancel(ctx)
+	}
+
+	// Duas falhas confirmadas deixam uma tentativa
+	for i := 0; i < 2; i++ {
+		_, reservation, err := limiter.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve deveria permitir, mas recebeu erro: %v", err)
+		}
+		decision, err := reservation.Commit(ctx)
+		if err != nil {
+			t.Fatalf("erro ao confirmar reserva: %v", err)
+		}
+		if !decision.Allowed || decision.Remaining != 2-i {
+			t.Errorf("decisão após %d confirmações deveria permitir com restante %d, mas recebeu %+v", i+1, 2-i, decision)
+		}
+	}
+
+	// A terceira falha atinge o limite e bloqueia a chave
+	_, reservation, err := limiter.Reserve(ctx, req)
+	if err != nil {
+		t.Fatalf("Reserve deveria permitir a última tentativa, mas recebeu erro: %v", err)
+	}
+	decision, err := reservation.Commit(ctx)
+	if err != nil {
+		t.Fatalf("erro ao confirmar reserva: %v", err)
+	}
+	if decision.Allowed || decision.Reset != time.Minute {
+		t.Errorf("decisão deveria bloquear por 1 minuto, mas recebeu %+v", decision)
+	}
+
+	_, _, err = limiter.Reserve(ctx, req)
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.RetryAfter != time.Minute {
+		t.Fatalf("Reserve deveria negar com RetryAfter de 1 minuto, mas recebeu: %v", err)
+	}
+
+	// Após o bloqueio, a chave volta a ser permitida
+	fakeClock.Advance(time.Minute)
+	if _, _, err := limiter.Reserve(ctx, req); err != nil {
+		t.Errorf("Reserve deveria permitir após o bloqueio, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_ReserveWithoutBlock(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t, WithTokenLimit(2, 0))
+
+	ctx := context.Background()
+	req := &LimiterRequest{Token: "abc"}
+
+	for i := 0; i < 2; i++ {
+		_, reservation, err := limiter.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve deveria permitir, mas recebeu erro: %v", err)
+		}
+		reservation.Commit(ctx)
+	}
+
+	// Sem tempo de bloqueio, a chave é negada até o fim da janela
+	decision, _, err := limiter.Reserve(ctx, req)
+	if !IsRejected(err) {
+		t.Fatalf("Reserve deveria negar no limite, mas recebeu: %v", err)
+	}
+	if decision.Reset != time.Second {
+		t.Errorf("reset deveria ser 1s, mas recebeu %v", decision.Reset)
+	}
+
+	fakeClock.Advance(time.Second)
+	if _, _, err := limiter.Reserve(ctx, req); err != nil {
+		t.Errorf("Reserve deveria permitir na nova janela, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_ReserveConcurrent(t *testing.T) {
+	limiter, _ := newTestLimiter(t, WithIPLimit(3, 0))
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	// Requisições simultâneas não passam do limite enquanto as reservas estão em andamento
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := limiter.Reserve(ctx, req); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 3 {
+		t.Errorf("deveria permitir 3 reservas simultâneas, mas permitiu %d", allowed)
+	}
+}
+
+func TestRateLimiter_CommitAfterCancel(t *testing.T) {
+	limiter, _ := newTestLimiter(t, WithIPLimit(4, time.Minute))
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	var reservations []*Reservation
+	for i := 0; i < 4; i++ {
+		_, reservation, err := limiter.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve deveria permitir, mas recebeu erro: %v", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	// As três primeiras reservas são canceladas ao mesmo tempo; a última, reservada no
+	// limite, é confirmada depois e não deve bloquear a chave
+	var wg sync.WaitGroup
+	for _, reservation := range reservations[:3] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation.Cancel(ctx)
+		}()
+	}
+	wg.Wait()
+
+	decision, err := reservations[3].Commit(ctx)
+	if err != nil {
+		t.Fatalf("erro ao confirmar reserva: %v", err)
+	}
+	if !decision.Allowed || decision.Remaining != 3 {
+		t.Errorf("decisão deveria permitir com restante 3, mas recebeu %+v", decision)
+	}
+	if _, _, err := limiter.Reserve(ctx, req); err != nil {
+		t.Errorf("Reserve deveria permitir abaixo do limite, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_CommitAndCancelConcurrent(t *testing.T) {
+	limiter, _ := newTestLimiter(t, WithIPLimit(20, time.Minute))
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	var reservations []*Reservation
+	for i := 0; i < 20; i++ {
+		_, reservation, err := limiter.Reserve(ctx, req)
+		if err != nil {
+			t.Fatalf("Reserve deveria permitir, mas recebeu erro: %v", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	// Metade confirma e metade cancela ao mesmo tempo: a contagem final é a das
+	// confirmações, que nunca chega ao limite
+	var wg sync.WaitGroup
+	for i, reservation := range reservations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				reservation.Cancel(ctx)
+				return
+			}
+			if _, err := reservation.Commit(ctx); err != nil {
+				t.Errorf("erro ao confirmar reserva: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	decision, _, err := limiter.Reserve(ctx, req)
+	if err != nil {
+		t.Fatalf("Reserve deveria permitir abaixo do limite, mas recebeu erro: %v", err)
+	}
+	if decision.Remaining != 9 {
+		t.Errorf("restante deveria ser 9, mas recebeu %d", decision.Remaining)
+	}
+}
@@ -0,0 +1,295 @@
+package ratelimiter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultEventBuffer é o tamanho padrão do buffer de cada assinatura do EventBus
+	DefaultEventBuffer = 1000
+
+	// MaxPendingUnblocks limita quantos EventUnblocked ficam agendados ao mesmo tempo.
+	// Acima do limite, os desbloqueios de novas chaves não são publicados e contam em
+	// EventBus.Dropped.
+	MaxPendingUnblocks = 10000
+
+	// unblockPollInterval é o intervalo em que os desbloqueios agendados são verificados
+	unblockPollInterval = 100 * time.Millisecond
+)
+
+// EventType identifica o tipo de um evento do rate limiter
+type EventType string
+
+const (
+	// EventLimitExceeded é emitido quando uma chave excede o limite da regra, uma vez
+	// por janela (na requisição que ultrapassa o limite)
+	EventLimitExceeded EventType = "limit_exceeded"
+	// EventBlocked é emitido quando uma chave é bloqueada pelo tempo de bloqueio da regra
+	EventBlocked EventType = "block"
+	// EventUnblocked é emitido quando o bloqueio de uma chave termina, pela instância que
+	// a bloqueou
+	EventUnblocked EventType = "unblock"
+)
+
+// Event descreve uma mudança de estado de uma chave do rate limiter
+type Event struct {
+	// Tipo do evento
+	Type EventType
+	// Chave afetada (por exemplo, ip:192.168.1.1). Os tokens são substituídos pela sua
+	// impressão digital (veja TokenFingerprint), como em token:9f86d081884c7d65.
+	Key string
+	// Nome da regra aplicada
+	Rule string
+	// Contagem da janela no momento do evento
+	Count int
+	// Limite da regra em vigor
+	Limit int
+	// Duração do bloqueio (apenas em EventBlocked e EventUnblocked)
+	Duration time.Duration
+	// Instante do evento
+	Time time.Time
+}
+
+// EventBus distribui os eventos do rate limiter para os assinantes, como webhooks e o
+// stream de eventos. A publicação nunca bloqueia as decisões: um assinante com o buffer
+// cheio perde o evento, contabilizado em Dropped.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+	closed      bool
+	dropped     atomic.Uint64
+}
+
+// NewEventBus cria um barramento de eventos sem assinantes
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe registra um assinante com um buffer de buffer eventos (0 usa
+// DefaultEventBuffer). O canal é fechado ao cancelar a assinatura ou ao fechar o
+// barramento.
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = DefaultEventBuffer
+	}
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish entrega o evento a todos os assinantes sem bloquear
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped retorna quantos eventos foram descartados por assinantes com o buffer cheio
+func (b *EventBus) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// Close fecha os canais de todos os assinantes; publicações posteriores são ignoradas
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for ch := range b.subscribers {
+		close(ch)
+	}
+	clear(b.subscribers)
+}
+
+// TokenFingerprint retorna a impressão digital de um token usada nas chaves dos eventos:
+// os primeiros 16 dígitos hexadecimais do SHA-256 do token. Permite localizar os eventos
+// de um token conhecido sem expor os tokens a quem consome os eventos.
+func TokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// eventKey substitui os tokens da chave pela sua impressão digital: o valor das chaves
+// token:<token> e dos descritores token=<token> das regras nomeadas
+func eventKey(key string) string {
+	if token, ok := strings.CutPrefix(key, "token:"); ok {
+		return "token:" + TokenFingerprint(token)
+	}
+	if !strings.HasPrefix(key, "rule:") {
+		return key
+	}
+
+	// Os nomes e valores dos descritores têm ":" e "=" escapados (veja descriptorKey). A
+	// impressão digital é a do token original, como em chaves token:<token>.
+	parts := strings.Split(key, ":")
+	for i := 2; i < len(parts); i++ {
+		if token, ok := strings.CutPrefix(parts[i], "token="); ok {
+			parts[i] = "token=" + TokenFingerprint(descriptorUnescaper.Replace(token))
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// unblockQueue guarda os EventUnblocked agendados, um por chave, até o fim do bloqueio
+// segundo o relógio do RateLimiter. Uma única goroutine verifica os vencidos enquanto há
+// desbloqueios pendentes.
+type unblockQueue struct {
+	mu      sync.Mutex
+	pending map[string]Event
+	max     int
+	running bool
+	closed  bool
+	done    chan struct{}
+}
+
+// newUnblockQueue cria uma fila com até max desbloqueios pendentes
+func newUnblockQueue(max int) *unblockQueue {
+	return &unblockQueue{
+		pending: make(map[string]Event),
+		max:     max,
+		done:    make(chan struct{}),
+	}
+}
+
+// close interrompe a verificação e descarta os desbloqueios pendentes
+func (q *unblockQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+	clear(q.pending)
+}
+
+// scheduleUnblock agenda o EventUnblocked para event.Time. Um novo bloqueio da mesma chave
+// substitui o desbloqueio anterior; com a fila cheia, o evento é descartado.
+func (rl *RateLimiter) scheduleUnblock(event Event) {
+	q := rl.unblocks
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	if _, ok := q.pending[event.Key]; !ok && len(q.pending) >= q.max {
+		rl.events.dropped.Add(1)
+		return
+	}
+	q.pending[event.Key] = event
+	if !q.running {
+		q.running = true
+		go rl.runUnblocks()
+	}
+}
+
+// runUnblocks publica os desbloqueios vencidos até a fila esvaziar ou ser fechada
+func (rl *RateLimiter) runUnblocks() {
+	q := rl.unblocks
+	ticker := time.NewTicker(unblockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-ticker.C:
+		}
+
+		now := rl.clock.Now()
+		var due []Event
+		q.mu.Lock()
+		for key, event := range q.pending {
+			if !now.Before(event.Time) {
+				due = append(due, event)
+				delete(q.pending, key)
+			}
+		}
+		idle := len(q.pending) == 0
+		if idle {
+			q.running = false
+		}
+		q.mu.Unlock()
+
+		for _, event := range due {
+			rl.events.Publish(event)
+		}
+		if idle {
+			return
+		}
+	}
+}
+
+// emitExceeded publica EventLimitExceeded quando a requisição é a primeira a ultrapassar
+// o limite na janela, evitando um evento por requisição negada
+func (rl *RateLimiter) emitExceeded(key string, rule Rule, count, cost int) {
+	if rl.events == nil || count-cost > rule.Limit {
+		return
+	}
+	rl.events.Publish(Event{
+		Type:  EventLimitExceeded,
+		Key:   eventKey(key),
+		Rule:  rule.Name,
+		Count: count,
+		Limit: rule.Limit,
+		Time:  rl.clock.Now(),
+	})
+}
+
+// emitBlocked publica EventBlocked e agenda o EventUnblocked para o fim do bloqueio,
+// medido pelo relógio do RateLimiter
+func (rl *RateLimiter) emitBlocked(key string, rule Rule, count int) {
+	if rl.events == nil {
+		return
+	}
+	event := Event{
+		Type:     EventBlocked,
+		Key:      eventKey(key),
+		Rule:     rule.Name,
+		Count:    count,
+		Limit:    rule.Limit,
+		Duration: rule.BlockTime,
+		Time:     rl.clock.Now(),
+	}
+	rl.events.Publish(event)
+
+	event.Type = EventUnblocked
+	event.Time = event.Time.Add(rule.BlockTime)
+	rl.scheduleUnblock(event)
+}
@@ -0,0 +1,151 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe(1)
+
+	// Com o buffer cheio, o evento é descartado sem bloquear a publicação
+	bus.Publish(Event{Type: EventBlocked, Key: "ip:1"})
+	bus.Publish(Event{Type: EventBlocked, Key: "ip:2"})
+	if event := <-events; event.Key != "ip:1" {
+		t.Errorf("deveria receber o primeiro evento, mas recebeu %+v", event)
+	}
+	if bus.Dropped() != 1 {
+		t.Errorf("deveria descartar 1 evento, mas descartou %d", bus.Dropped())
+	}
+
+	// Cancelar a assinatura fecha o canal
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("canal deveria ser fechado ao cancelar a assinatura")
+	}
+
+	// Fechar o barramento fecha os canais restantes
+	other, _ := bus.Subscribe(0)
+	bus.Close()
+	if _, ok := <-other; ok {
+		t.Error("canal deveria ser fechado ao fechar o barramento")
+	}
+}
+
+func TestRateLimiter_Events(t *testing.T) {
+	bus := NewEventBus()
+	defer bus.Close()
+	events, _ := bus.Subscribe(0)
+
+	blockTime := 50 * time.Millisecond
+	limiter, fakeClock := newTestLimiter(t,
+		WithIPLimit(2, 0),
+		WithTokenLimit(1, blockTime),
+		WithEvents(bus),
+	)
+
+	ctx := context.Background()
+
+	// Sem tempo de bloqueio, apenas a primeira requisição acima do limite gera evento
+	ipReq := &LimiterRequest{IP: "192.168.1.1"}
+	for i := 0; i < 5; i++ {
+		limiter.Allow(ctx, ipReq)
+	}
+	event := <-events
+	if event.Type != EventLimitExceeded || event.Key != "ip:192.168.1.1" || event.Rule != IPRuleName || event.Count != 3 || event.Limit != 2 {
+		t.Errorf("evento de limite excedido inesperado: %+v", event)
+	}
+	if len(events) != 0 {
+		t.Errorf("não deveria emitir eventos para as demais requisições negadas, mas há %d", len(events))
+	}
+
+	// Com tempo de bloqueio, o limite excedido é seguido do bloqueio e, ao fim dele, do
+	// desbloqueio; o token aparece apenas pela impressão digital
+	tokenReq := &LimiterRequest{IP: "192.168.1.1", Token: "abc"}
+	limiter.Allow(ctx, tokenReq)
+	limiter.Allow(ctx, tokenReq)
+	blockedAt := fakeClock.Now()
+
+	tokenKey := "token:" + TokenFingerprint("abc")
+	want := []EventType{EventLimitExceeded, EventBlocked, EventUnblocked}
+	for _, eventType := range want {
+		// O desbloqueio segue o relógio do RateLimiter
+		if eventType == EventUnblocked {
+			fakeClock.Advance(blockTime)
+		}
+		select {
+		case event = <-events:
+		case <-time.After(time.Second):
+			t.Fatalf("deveria emitir o evento %s", eventType)
+		}
+		if event.Type != eventType || event.Key != tokenKey {
+			t.Fatalf("evento deveria ser %s de %s, mas recebeu %+v", eventType, tokenKey, event)
+		}
+	}
+	if event.Duration != blockTime {
+		t.Errorf("duração do bloqueio deveria ser %v, mas recebeu %v", blockTime, event.Duration)
+	}
+	if want := blockedAt.Add(blockTime); !event.Time.Equal(want) {
+		t.Errorf("desbloqueio deveria ocorrer em %v, mas recebeu %v", want, event.Time)
+	}
+}
+
+func TestRateLimiter_PendingUnblocksBounded(t *testing.T) {
+	bus := NewEventBus()
+	defer bus.Close()
+	events, _ := bus.Subscribe(0)
+
+	limiter, fakeClock := newTestLimiter(t, WithIPLimit(1, time.Minute), WithEvents(bus))
+	limiter.unblocks.max = 1
+
+	// Só cabe um desbloqueio pendente; o da segunda chave é descartado
+	ctx := context.Background()
+	for _, ip := range []string{"192.168.1.1", "192.168.1.2"} {
+		limiter.Allow(ctx, &LimiterRequest{IP: ip})
+		limiter.Allow(ctx, &LimiterRequest{IP: ip})
+	}
+	if bus.Dropped() != 1 {
+		t.Errorf("deveria descartar 1 desbloqueio, mas descartou %d", bus.Dropped())
+	}
+
+	fakeClock.Advance(time.Minute)
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type != EventUnblocked {
+				continue
+			}
+			if event.Key != "ip:192.168.1.1" {
+				t.Errorf("desbloqueio deveria ser da primeira chave, mas recebeu %+v", event)
+			}
+			return
+		case <-deadline:
+			t.Fatal("deveria emitir o desbloqueio da primeira chave")
+		}
+	}
+}
+
+func TestEventKey(t *testing.T) {
+	fingerprint := TokenFingerprint("abc")
+	tests := map[string]string{
+		"ip:192.168.1.1":                   "ip:192.168.1.1",
+		"token:abc":                        "token:" + fingerprint,
+		"rule:partners:partner=acme":       "rule:partners:partner=acme",
+		"rule:orders:route=/x:token=abc":   "rule:orders:route=/x:token=" + fingerprint,
+		"rule:orders:ip=10.0.0.1:route=/x": "rule:orders:ip=10.0.0.1:route=/x",
+	}
+	// Tokens com separadores escapados (base64 com "=", por exemplo) têm a mesma
+	// impressão digital do token original
+	for _, token := range []string{"YWJj=", "a:b", "100%3D"} {
+		key := descriptorKey("orders", map[string]string{"token": token})
+		tests[key] = "rule:orders:token=" + TokenFingerprint(token)
+	}
+	for key, want := range tests {
+		if got := eventKey(key); got != want {
+			t.Errorf("chave do evento de %s deveria ser %s, mas recebeu %s", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GlobalLimit é um limite agregado, compartilhado por todos os clientes, que protege o
+// backend (por exemplo, 2000 requisições por segundo em /checkout). É verificado depois
+// do limite do cliente, então requisições já negadas não consomem o orçamento global.
+type GlobalLimit struct {
+	// Nome do limite, usado na chave do contador (por exemplo, "checkout")
+	Name string
+	// Prefixo do caminho ao qual o limite se aplica, comparado por segmentos ("/checkout"
+	// inclui "/checkout/pay", mas não "/checkouts"; "" aplica a todas as requisições)
+	PathPrefix string
+	// Número máximo de requisições (ou custo acumulado) por janela, somando todos os clientes
+	Limit int
+	// Duração da janela (0 equivale a um segundo)
+	Window time.Duration
+	// Fração do limite que um único cliente pode consumir por janela, entre 0 e 1
+	// (0 desativa a divisão justa)
+	MaxShare float64
+}
+
+// window retorna a duração da janela do limite, tratando zero como um segundo
+func (g GlobalLimit) window() time.Duration {
+	if g.Window <= 0 {
+		return time.Second
+	}
+	return g.Window
+}
+
+// share retorna quanto um único cliente pode consumir por janela (0 se não há divisão justa)
+func (g GlobalLimit) share() int {
+	if g.MaxShare <= 0 || g.MaxShare >= 1 {
+		return 0
+	}
+	return max(1, int(math.Ceil(float64(g.Limit)*g.MaxShare)))
+}
+
+// matches indica se o limite se aplica ao caminho da requisição. O prefixo é comparado
+// por segmentos: /checkout se aplica a /checkout e /checkout/pay, mas não a /checkouts.
+func (g GlobalLimit) matches(path string) bool {
+	prefix := g.PathPrefix
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || prefix == "" || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// GlobalLimitExceededError é retornado quando um limite global é excedido, seja o
+// orçamento total, a parcela justa do cliente ou a capacidade disponível para a
+// prioridade da requisição
+type GlobalLimitExceededError struct {
+	// Nome do limite global excedido
+	Limit string
+	// Indica que o cliente excedeu sua parcela do limite, e não o orçamento total
+	FairShare bool
+	// Indica que a requisição foi descartada pela política de prioridade antes de o
+	// limite ser atingido
+	Shed bool
+	// Prioridade da requisição
+	Priority Priority
+	// Mensagem de erro
+	Message string
+	// Tempo até o fim da janela atual
+	RetryAfter time.Duration
+}
+
+// Error implementa a interface error
+func (e *GlobalLimitExceededError) Error() string {
+	return e.Message
+}
+
+// NewGlobalLimitExceededError cria um novo erro de limite global excedido
+func NewGlobalLimitExceededError(limit string, fairShare bool) *GlobalLimitExceededError {
+	message := fmt.Sprintf("the service is receiving too many requests (%s limit)", limit)
+	if fairShare {
+		message = fmt.Sprintf("you have reached your share of the %s limit", limit)
+	}
+	return &GlobalLimitExceededError{
+		Limit:     limit,
+		FairShare: fairShare,
+		Message:   message,
+	}
+}
+
+// ParseGlobalLimits converte uma lista no formato /checkout=2000,/=10000 em limites
+// globais por segundo, um por prefixo de caminho, com a mesma parcela máxima por cliente
+func ParseGlobalLimits(value string, maxShare float64) ([]GlobalLimit, error) {
+	var limits []GlobalLimit
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, limit, ok := strings.Cut(entry, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("limite global inválido: %s", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("limite global inválido para %s: %s", prefix, limit)
+		}
+		limits = append(limits, GlobalLimit{Name: prefix, PathPrefix: prefix, Limit: n, MaxShare: maxShare})
+	}
+
+	return limits, nil
+}
+
+// consumeGlobalLimits aplica os limites globais que correspondem ao caminho. Para cada
+// limite, a parcela do cliente é contada antes do total, de modo que um cliente acima da
+// sua parcela não consome o orçamento dos demais, e a política de descarte é aplicada
+// conforme a prioridade. Retorna o erro do primeiro limite excedido; os contadores
+// incrementados são acrescentados a charges para que Decide possa devolvê-los.
+func (rl *RateLimiter) consumeGlobalLimits(ctx context.Context, path, clientKey string, priority Priority, cost int, charges *[]charge) (*GlobalLimitExceededError, error) {
+	now := rl.clock.Now()
+	for _, global := range rl.globals {
+		if !global.matches(path) {
+			continue
+		}
+
+		window := global.window()
+		windowStart := now.Truncate(window)
+		reset := windowStart.Add(window).Sub(now)
+
+		if share := global.share(); share > 0 {
+			shareKey := fmt.Sprintf("global:%s:%s:%d", global.Name, clientKey, windowStart.UnixMilli())
+			count, err := rl.store.IncrementRequestCountBy(ctx, shareKey, cost, reset)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao verificar limite global %s: %w", global.Name, err)
+			}
+			*charges = append(*charges, charge{key: shareKey, cost: cost, expiration: reset})
+			if count > share {
+				exceeded := NewGlobalLimitExceededError(global.Name, true)
+				exceeded.RetryAfter = reset
+				return exceeded, nil
+			}
+		}
+
+		totalKey := fmt.Sprintf("global:%s:%d", global.Name, windowStart.UnixMilli())
+
+		// Com a capacidade reservada para classes mais altas, descarta a requisição sem
+		// consumir o orçamento. A leitura não é atômica com o incremento, então a reserva
+		// é aproximada sob concorrência; o limite total continua exato.
+		if admitted := rl.shedding.admitted(priority, global.Limit); admitted < global.Limit {
+			count, err := rl.store.GetRequestCount(ctx, totalKey)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao verificar limite global %s: %w", global.Name, err)
+			}
+			if count+cost > admitted {
+				exceeded := NewGlobalLimitExceededError(global.Name, false)
+				exceeded.Shed = true
+				exceeded.Priority = priority
+				exceeded.Message = fmt.Sprintf("the service is shedding %s priority traffic (%s limit)", priority, global.Name)
+				exceeded.RetryAfter = reset
+				return exceeded, nil
+			}
+		}
+
+		count, err := rl.store.IncrementRequestCountBy(ctx, totalKey, cost, reset)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar limite global %s: %w", global.Name, err)
+		}
+		*charges = append(*charges, charge{key: totalKey, cost: cost, expiration: reset})
+		if count > global.Limit {
+			exceeded := NewGlobalLimitExceededError(global.Name, false)
+			exceeded.Priority = priority
+			exceeded.RetryAfter = reset
+			return exceeded, nil
+		}
+	}
+
+	return nil, nil
+}
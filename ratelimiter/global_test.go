@@ -0,0 +1,168 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_GlobalLimit(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		WithIPLimit(100, 0),
+		WithGlobalLimits(GlobalLimit{Name: "checkout", PathPrefix: "/checkout", Limit: 4}),
+	)
+
+	ctx := context.Background()
+
+	// O orçamento é compartilhado por todos os clientes
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		if err := limiter.Allow(ctx, &LimiterRequest{IP: ip, Path: "/checkout/pay"}); err != nil {
+			t.Fatalf("deveria permitir requisição de %s, mas recebeu erro: %v", ip, err)
+		}
+	}
+
+	decision, err := limiter.Decide(ctx, &LimiterRequest{IP: "10.0.0.5", Path: "/checkout"})
+	var globalErr *GlobalLimitExceededError
+	if !errors.As(err, &globalErr) {
+		t.Fatalf("erro deveria ser GlobalLimitExceededError, mas recebeu: %v", err)
+	}
+	if globalErr.Limit != "checkout" || globalErr.FairShare {
+		t.Errorf("erro deveria indicar o orçamento total de checkout, mas recebeu %+v", globalErr)
+	}
+	if decision.Allowed || decision.Reset != time.Second {
+		t.Errorf("decisão deveria negar com reset de 1s, mas recebeu allowed=%v reset=%v", decision.Allowed, decision.Reset)
+	}
+	if !IsRejected(err) {
+		t.Error("IsRejected deveria reconhecer GlobalLimitExceededError")
+	}
+
+	// Outros caminhos não são afetados
+	if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.5", Path: "/catalog"}); err != nil {
+		t.Errorf("deveria permitir caminho sem limite global, mas recebeu erro: %v", err)
+	}
+
+	// O orçamento é renovado na próxima janela
+	fakeClock.Advance(time.Second)
+	if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.5", Path: "/checkout"}); err != nil {
+		t.Errorf("deveria permitir requisição na nova janela, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_GlobalLimitFairShare(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		WithIPLimit(100, 0),
+		WithTokenLimit(3, 0),
+		WithGlobalLimits(GlobalLimit{Name: "all", Limit: 10, MaxShare: 0.2}),
+	)
+
+	ctx := context.Background()
+
+	// Cada cliente pode consumir no máximo 20% do orçamento (2 requisições)
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/"}); err != nil {
+			t.Fatalf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/"})
+	var globalErr *GlobalLimitExceededError
+	if !errors.As(err, &globalErr) || !globalErr.FairShare {
+		t.Fatalf("erro deveria indicar parcela excedida, mas recebeu: %v", err)
+	}
+
+	// Requisições acima da parcela não consomem o orçamento dos demais: restam 8 vagas
+	for i := 0; i < 3; i++ {
+		limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/"})
+	}
+	for _, ip := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"} {
+		for i := 0; i < 2; i++ {
+			if err := limiter.Allow(ctx, &LimiterRequest{IP: ip, Path: "/"}); err != nil {
+				t.Fatalf("deveria permitir requisição %d de %s, mas recebeu erro: %v", i+1, ip, err)
+			}
+		}
+	}
+
+	// Orçamento total esgotado
+	if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.6", Path: "/"}); !errors.As(err, &globalErr) || globalErr.FairShare {
+		t.Errorf("erro deveria indicar orçamento total esgotado, mas recebeu: %v", err)
+	}
+
+	// Requisições negadas pelo limite do cliente não chegam ao limite global
+	fakeClock.Advance(time.Second)
+	for i := 0; i < 5; i++ {
+		limiter.Allow(ctx, &LimiterRequest{Token: "abc", Path: "/"})
+	}
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		for i := 0; i < 2; i++ {
+			if err := limiter.Allow(ctx, &LimiterRequest{IP: ip, Path: "/"}); err != nil {
+				t.Fatalf("deveria permitir requisição %d de %s, mas recebeu erro: %v", i+1, ip, err)
+			}
+		}
+	}
+}
+
+func TestRateLimiter_GlobalLimitDenialRefundsCounters(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		WithIPLimit(2, 0),
+		WithGlobalLimits(
+			GlobalLimit{Name: "all", Limit: 2},
+			GlobalLimit{Name: "checkout", PathPrefix: "/checkout", Limit: 1},
+		),
+	)
+
+	ctx := context.Background()
+
+	if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/checkout"}); err != nil {
+		t.Fatalf("deveria permitir primeira requisição, mas recebeu erro: %v", err)
+	}
+
+	// Negadas pelo limite de checkout, as requisições devolvem o limite do cliente e o
+	// orçamento do limite "all", verificado antes
+	var globalErr *GlobalLimitExceededError
+	for i := 0; i < 3; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/checkout/pay"}); !errors.As(err, &globalErr) || globalErr.Limit != "checkout" {
+			t.Fatalf("erro deveria indicar o limite de checkout, mas recebeu: %v", err)
+		}
+	}
+	if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/catalog"}); err != nil {
+		t.Errorf("deveria permitir requisição após as negações, mas recebeu erro: %v", err)
+	}
+}
+
+func TestGlobalLimit_MatchesSegments(t *testing.T) {
+	global := GlobalLimit{PathPrefix: "/checkout"}
+	tests := map[string]bool{
+		"/checkout":     true,
+		"/checkout/pay": true,
+		"/checkouts":    false,
+		"/catalog":      false,
+	}
+	for path, want := range tests {
+		if got := global.matches(path); got != want {
+			t.Errorf("matches(%q) deveria ser %v, mas recebeu %v", path, want, got)
+		}
+	}
+
+	if !(GlobalLimit{PathPrefix: "/"}).matches("/any") || !(GlobalLimit{}).matches("/any") {
+		t.Error("os prefixos / e vazio deveriam corresponder a todos os caminhos")
+	}
+}
+
+func TestParseGlobalLimits(t *testing.T) {
+	limits, err := ParseGlobalLimits("/checkout=2000, /=10000", 0.1)
+	if err != nil {
+		t.Fatalf("erro ao interpretar limites globais: %v", err)
+	}
+	if len(limits) != 2 {
+		t.Fatalf("deveria interpretar 2 limites, mas recebeu %d", len(limits))
+	}
+	if limits[0] != (GlobalLimit{Name: "/checkout", PathPrefix: "/checkout", Limit: 2000, MaxShare: 0.1}) {
+		t.Errorf("primeiro limite inesperado: %+v", limits[0])
+	}
+
+	for _, value := range []string{"checkout=10", "/checkout", "/checkout=0", "/checkout=abc"} {
+		if _, err := ParseGlobalLimits(value, 0); err == nil {
+			t.Errorf("deveria rejeitar %q", value)
+		}
+	}
+}
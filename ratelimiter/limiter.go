@@ -0,0 +1,364 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// RateLimiter controla a limitação de requisições
+type RateLimiter struct {
+	config    *LimiterConfig
+	rules     map[string]Rule
+	quotas    map[LimitType][]Quota
+	adaptive  map[string]*adaptiveLimit
+	schedules map[string]*Schedule
+	globals   []GlobalLimit
+	shedding  SheddingPolicy
+	bandwidth map[LimitType]BandwidthLimit
+	events    *EventBus
+	unblocks  *unblockQueue
+	location  *time.Location
+	store     store.RateLimiterStore
+	clock     clock.Clock
+}
+
+// New cria um RateLimiter sobre o armazenamento informado. Sem opções, usa os
+// limites padrão (DefaultIPLimit, DefaultTokenLimit...). O RateLimiter assume o
+// armazenamento e o fecha em Close.
+func New(limiterStore store.RateLimiterStore, opts ...Option) *RateLimiter {
+	rl := &RateLimiter{
+		config: &LimiterConfig{
+			IPLimit:        DefaultIPLimit,
+			IPBlockTime:    DefaultIPBlockTime,
+			TokenLimit:     DefaultTokenLimit,
+			TokenBlockTime: DefaultTokenBlockTime,
+
+			ConcurrencyLeaseTTL: DefaultConcurrencyLeaseTTL,
+		},
+		rules:     make(map[string]Rule),
+		quotas:    make(map[LimitType][]Quota),
+		adaptive:  make(map[string]*adaptiveLimit),
+		schedules: make(map[string]*Schedule),
+		bandwidth: make(map[LimitType]BandwidthLimit),
+		unblocks:  newUnblockQueue(MaxPendingUnblocks),
+		location:  time.UTC,
+		store:     limiterStore,
+		clock:     clock.New(),
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+
+	return rl
+}
+
+// NewRateLimiter cria uma nova instância do RateLimiter usando uma factory de armazenamento
+func NewRateLimiter(storeFactory store.Factory, opts ...Option) (*RateLimiter, error) {
+	limiterStore, err := storeFactory()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar armazenamento: %w", err)
+	}
+
+	return New(limiterStore, opts...), nil
+}
+
+// Allow verifica se uma requisição deve ser permitida ou bloqueada
+func (rl *RateLimiter) Allow(ctx context.Context, req *LimiterRequest) error {
+	_, err := rl.Decide(ctx, req)
+	return err
+}
+
+// Decide avalia uma requisição e retorna a decisão com o limite, o saldo restante e o
+// tempo até o reset. Quando a requisição é negada, retorna também um *LimitExceededError
+// (limite por segundo), um *GlobalLimitExceededError (limite global) ou um
+// *QuotaExceededError (cota de longo prazo). Uma requisição negada por um limite global
+// ou por uma cota não consome nenhum dos limites: os contadores já incrementados são
+// devolvidos.
+func (rl *RateLimiter) Decide(ctx context.Context, req *LimiterRequest) (*Decision, error) {
+	cost, err := requestCost(req.Cost)
+	if err != nil {
+		return nil, err
+	}
+
+	limitType, key, rule := rl.clientRule(req)
+	decision, err := rl.evaluate(ctx, key, rule, cost)
+	if err != nil {
+		if limitType == TokenLimit {
+			return nil, fmt.Errorf("erro ao verificar limite do token: %w", err)
+		}
+		return nil, fmt.Errorf("erro ao verificar limite do IP: %w", err)
+	}
+	if !decision.Allowed {
+		return decision, decisionError(decision, limitType)
+	}
+
+	// Os consumos são devolvidos se um limite global ou uma cota negarem a requisição
+	charges := []charge{rl.windowCharge(key, rule, cost)}
+
+	// Os limites globais só são consumidos por requisições dentro do limite do cliente
+	globalExceeded, err := rl.consumeGlobalLimits(ctx, req.Path, key, requestPriority(req), cost, &charges)
+	if err != nil {
+		rl.refund(ctx, charges)
+		return nil, err
+	}
+	if globalExceeded != nil {
+		rl.refund(ctx, charges)
+		decision.Allowed = false
+		decision.Remaining += cost
+		decision.Reset = globalExceeded.RetryAfter
+		return decision, globalExceeded
+	}
+
+	// As cotas de longo prazo só são consumidas por requisições dentro do limite por segundo
+	usages, exceeded, err := rl.consumeQuotas(ctx, limitType, key, cost, &charges)
+	if err != nil {
+		rl.refund(ctx, charges)
+		return nil, err
+	}
+	decision.Quotas = usages
+	if exceeded != nil {
+		rl.refund(ctx, charges)
+		decision.Allowed = false
+		decision.Remaining += cost
+		decision.Reset = exceeded.RetryAfter
+		return decision, exceeded
+	}
+
+	return decision, nil
+}
+
+// Check avalia uma requisição contra uma regra nomeada. A chave é formada pelos
+// descritores, então cada combinação de valores tem seu próprio contador. Uma
+// requisição negada não é um erro: o resultado vem em Decision.Allowed.
+func (rl *RateLimiter) Check(ctx context.Context, req *CheckRequest) (*Decision, error) {
+	rule, ok := rl.rule(req.Rule)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRule, req.Rule)
+	}
+
+	cost, err := requestCost(req.Cost)
+	if err != nil {
+		return nil, err
+	}
+
+	decision, err := rl.evaluate(ctx, descriptorKey(rule.Name, req.Descriptors), rule, cost)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar limite da regra %s: %w", rule.Name, err)
+	}
+	return decision, nil
+}
+
+// evaluate aplica uma regra a uma chave: bloqueia a chave se a contagem da janela passar
+// do limite
+func (rl *RateLimiter) evaluate(ctx context.Context, key string, rule Rule, cost int) (*Decision, error) {
+	rule, period := rl.effectiveRule(rule)
+	decision := &Decision{
+		Rule:     rule.Name,
+		Key:      key,
+		Limit:    rule.Limit,
+		Schedule: period,
+		Window:   rule.window(),
+	}
+
+	// Verifica se a chave está bloqueada (regras sem tempo de bloqueio nunca bloqueiam)
+	if rule.BlockTime > 0 {
+		ttl, err := rl.store.BlockTTL(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			decision.Reset = ttl
+			return decision, nil
+		}
+	}
+
+	// Incrementa o contador da janela atual
+	count, reset, err := rl.incrementWindow(ctx, key, rule, cost)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verifica se excedeu o limite
+	if count > rule.Limit {
+		decision.Reset = reset
+		rl.emitExceeded(key, rule, count, cost)
+
+		// Bloqueia a chave pelo tempo configurado
+		if rule.BlockTime > 0 {
+			if err := rl.store.Block(ctx, key, rule.BlockTime); err != nil {
+				return nil, err
+			}
+			decision.Reset = rule.BlockTime
+			rl.emitBlocked(key, rule, count)
+		}
+		return decision, nil
+	}
+
+	decision.Allowed = true
+	decision.Remaining = rule.Limit - count
+	decision.Reset = reset
+	return decision, nil
+}
+
+// incrementWindow soma o custo ao contador da janela atual da regra e retorna a contagem e
+// o tempo até o fim da janela. Por padrão, a janela começa na primeira requisição e o
+// contador usa a própria chave, que o bloqueio reinicia; regras com Aligned usam uma
+// chave por janela alinhada ao relógio.
+func (rl *RateLimiter) incrementWindow(ctx context.Context, key string, rule Rule, cost int) (int, time.Duration, error) {
+	if rule.Aligned {
+		windowKey, reset := rl.alignedWindow(key, rule)
+		count, err := rl.store.IncrementRequestCountBy(ctx, windowKey, cost, reset)
+		return count, reset, err
+	}
+
+	window := rule.window()
+	if ts, ok := rl.store.(store.CounterTTLStore); ok {
+		return ts.IncrementRequestCountTTL(ctx, key, cost, window)
+	}
+
+	// Sem o TTL do contador, a janela inteira é o limite superior do tempo até o reset
+	count, err := rl.store.IncrementRequestCountBy(ctx, key, cost, window)
+	return count, window, err
+}
+
+// windowCount retorna a contagem da janela atual da regra sem consumir o limite, e o
+// tempo até o fim da janela (a janela inteira quando ainda não há contador)
+func (rl *RateLimiter) windowCount(ctx context.Context, key string, rule Rule) (int, time.Duration, error) {
+	if rule.Aligned {
+		windowKey, reset := rl.alignedWindow(key, rule)
+		count, err := rl.store.GetRequestCount(ctx, windowKey)
+		return count, reset, err
+	}
+
+	window := rule.window()
+	if ts, ok := rl.store.(store.CounterTTLStore); ok {
+		count, ttl, err := ts.GetRequestCountTTL(ctx, key)
+		if ttl <= 0 {
+			ttl = window
+		}
+		return count, ttl, err
+	}
+
+	count, err := rl.store.GetRequestCount(ctx, key)
+	return count, window, err
+}
+
+// alignedWindow retorna a chave do contador da janela alinhada ao relógio em que o
+// instante atual está e o tempo até o fim dessa janela
+func (rl *RateLimiter) alignedWindow(key string, rule Rule) (string, time.Duration) {
+	now := rl.clock.Now()
+	window := rule.window()
+	windowStart := now.Truncate(window)
+
+	return fmt.Sprintf("%s:%d", key, windowStart.UnixMilli()), windowStart.Add(window).Sub(now)
+}
+
+// charge é um contador consumido por Decide, devolvido quando uma etapa posterior nega a
+// requisição
+type charge struct {
+	key        string
+	cost       int
+	expiration time.Duration
+}
+
+// windowCharge retorna o consumo do contador da janela da regra
+func (rl *RateLimiter) windowCharge(key string, rule Rule, cost int) charge {
+	if rule.Aligned {
+		windowKey, reset := rl.alignedWindow(key, rule)
+		return charge{key: windowKey, cost: cost, expiration: reset}
+	}
+	return charge{key: key, cost: cost, expiration: rule.window()}
+}
+
+// refund devolve os consumos de uma requisição negada. Usa um contexto sem cancelamento,
+// já que a requisição pode ter sido cancelada; falhas são ignoradas e, no pior caso, a
+// requisição negada continua contada até o fim da janela.
+func (rl *RateLimiter) refund(ctx context.Context, charges []charge) {
+	ctx = context.WithoutCancel(ctx)
+	for _, c := range charges {
+		rl.store.IncrementRequestCountBy(ctx, c.key, -c.cost, c.expiration)
+	}
+}
+
+// clientRule retorna o tipo de limitação, a chave e a regra do cliente da requisição.
+// Se um token foi fornecido, ele tem prioridade sobre o IP.
+func (rl *RateLimiter) clientRule(req *LimiterRequest) (LimitType, string, Rule) {
+	if req.Token != "" {
+		return TokenLimit, req.ClientKey(), rl.tokenRule()
+	}
+	return IPLimit, req.ClientKey(), rl.ipRule()
+}
+
+// effectiveRule aplica à regra o limite do período do agendamento em vigor e, em seguida,
+// o limite adaptativo. Retorna também o nome do período ("" sem agendamento em vigor).
+func (rl *RateLimiter) effectiveRule(rule Rule) (Rule, string) {
+	rule, period := rl.applySchedule(rule)
+	return rl.applyAdaptive(rule), period
+}
+
+// rule busca uma regra pelo nome, incluindo as regras internas de IP e token.
+// O limite retornado é o estático; evaluate aplica o agendamento e o limite adaptativo.
+func (rl *RateLimiter) rule(name string) (Rule, bool) {
+	switch name {
+	case IPRuleName:
+		return rl.ipRule(), true
+	case TokenRuleName:
+		return rl.tokenRule(), true
+	}
+
+	rule, ok := rl.rules[name]
+	return rule, ok
+}
+
+// ipRule retorna a regra de limitação por IP
+func (rl *RateLimiter) ipRule() Rule {
+	return Rule{
+		Name:      IPRuleName,
+		Limit:     rl.config.IPLimit,
+		Window:    time.Second,
+		BlockTime: rl.config.IPBlockTime,
+	}
+}
+
+// tokenRule retorna a regra de limitação por token
+func (rl *RateLimiter) tokenRule() Rule {
+	return Rule{
+		Name:      TokenRuleName,
+		Limit:     rl.config.TokenLimit,
+		Window:    time.Second,
+		BlockTime: rl.config.TokenBlockTime,
+	}
+}
+
+// requestCost valida o custo de uma requisição, tratando zero como um
+func requestCost(cost int) (int, error) {
+	if cost < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidCost, cost)
+	}
+	if cost == 0 {
+		return 1, nil
+	}
+	return cost, nil
+}
+
+// decisionError retorna um *LimitExceededError se a decisão negou a requisição
+func decisionError(decision *Decision, limitType LimitType) error {
+	if decision.Allowed {
+		return nil
+	}
+
+	err := NewLimitExceededError(limitType)
+	err.RetryAfter = decision.Reset
+	return err
+}
+
+// Close descarta os eventos de desbloqueio agendados e fecha o armazenamento do rate
+// limiter
+func (rl *RateLimiter) Close() error {
+	rl.unblocks.close()
+	return rl.store.Close()
+}
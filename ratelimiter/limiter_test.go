@@ -0,0 +1,550 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// newTestLimiter cria um rate limiter em memória com um relógio falso compartilhado com o
+// armazenamento. O rate limiter é fechado ao fim do teste.
+func newTestLimiter(t *testing.T, opts ...Option) (*RateLimiter, *clock.Fake) {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	opts = append(opts, WithClock(fakeClock))
+	limiter := New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), opts...)
+	t.Cleanup(func() { limiter.Close() })
+
+	return limiter, fakeClock
+}
+
+func TestRateLimiter_IP(t *testing.T) {
+	// Cria configuração de teste
+	cfg := &LimiterConfig{
+		IPLimit:        3,
+		IPBlockTime:    5 * time.Minute,
+		TokenLimit:     5,
+		TokenBlockTime: 5 * time.Minute,
+	}
+
+	// Factory para armazenamento em memória
+	storeFactory := func() (store.RateLimiterStore, error) {
+		return store.NewMemoryStore(), nil
+	}
+
+	// Cria rate limiter
+	limiter, err := NewRateLimiter(storeFactory, WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime))
+	if err != nil {
+		t.Fatalf("falha ao criar rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	// Cria contexto para testes
+	ctx := context.Background()
+
+	// Testa limite por IP
+	ip := "192.168.1.1"
+	req := &LimiterRequest{
+		IP: ip,
+	}
+
+	// Deve permitir requisições até o limite
+	for i := 0; i < cfg.IPLimit; i++ {
+		if err := limiter.Allow(ctx, req); err != nil {
+			t.Errorf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+
+	// A próxima requisição deve ser bloqueada
+	err = limiter.Allow(ctx, req)
+	if err == nil {
+		t.Error("deveria bloquear requisição acima do limite, mas permitiu")
+	}
+
+	// Verifica se o erro é do tipo correto
+	limitErr, ok := err.(*LimitExceededError)
+	if !ok {
+		t.Errorf("erro deveria ser do tipo LimitExceededError, mas recebeu: %T", err)
+	}
+
+	// Verifica se o tipo de limite é o correto
+	if limitErr.Type != IPLimit {
+		t.Errorf("tipo de limite deveria ser IPLimit, mas recebeu: %v", limitErr.Type)
+	}
+}
+
+func TestRateLimiter_Token(t *testing.T) {
+	// Cria configuração de teste
+	cfg := &LimiterConfig{
+		IPLimit:        3,
+		IPBlockTime:    5 * time.Minute,
+		TokenLimit:     5,
+		TokenBlockTime: 5 * time.Minute,
+	}
+
+	// Factory para armazenamento em memória
+	storeFactory := func() (store.RateLimiterStore, error) {
+		return store.NewMemoryStore(), nil
+	}
+
+	// Cria rate limiter
+	limiter, err := NewRateLimiter(storeFactory, WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime))
+	if err != nil {
+		t.Fatalf("falha ao criar rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	// Cria contexto para testes
+	ctx := context.Background()
+
+	// Testa limite por Token
+	ip := "192.168.1.1"
+	token := "test-token"
+	req := &LimiterRequest{
+		IP:    ip,
+		Token: token,
+	}
+
+	// Deve permitir requisições até o limite
+	for i := 0; i < cfg.TokenLimit; i++ {
+		if err := limiter.Allow(ctx, req); err != nil {
+			t.Errorf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+
+	// A próxima requisição deve ser bloqueada
+	err = limiter.Allow(ctx, req)
+	if err == nil {
+		t.Error("deveria bloquear requisição acima do limite, mas permitiu")
+	}
+
+	// Verifica se o erro é do tipo correto
+	limitErr, ok := err.(*LimitExceededError)
+	if !ok {
+		t.Errorf("erro deveria ser do tipo LimitExceededError, mas recebeu: %T", err)
+	}
+
+	// Verifica se o tipo de limite é o correto
+	if limitErr.Type != TokenLimit {
+		t.Errorf("tipo de limite deveria ser TokenLimit, mas recebeu: %v", limitErr.Type)
+	}
+}
+
+func TestRateLimiter_TokenOverridesIP(t *testing.T) {
+	// Cria configuração de teste com limite de token maior que o de IP
+	cfg := &LimiterConfig{
+		IPLimit:        3,
+		IPBlockTime:    5 * time.Minute,
+		TokenLimit:     10, // Maior que o limite de IP
+		TokenBlockTime: 5 * time.Minute,
+	}
+
+	// Factory para armazenamento em memória
+	storeFactory := func() (store.RateLimiterStore, error) {
+		return store.NewMemoryStore(), nil
+	}
+
+	// Cria rate limiter
+	limiter, err := NewRateLimiter(storeFactory, WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime))
+	if err != nil {
+		t.Fatalf("falha ao criar rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	// Cria contexto para testes
+	ctx := context.Background()
+
+	// Usa o mesmo IP para ambos os testes
+	ip := "192.168.1.1"
+
+	// Primeiro, faz requisições só com IP até quase atingir o limite
+	ipReq := &LimiterRequest{
+		IP: ip,
+	}
+
+	// Faz requisições até quase atingir o limite de IP
+	for i := 0; i < cfg.IPLimit-1; i++ {
+		if err := limiter.Allow(ctx, ipReq); err != nil {
+			t.Errorf("deveria permitir requisição %d com IP, mas recebeu erro: %v", i+1, err)
+		}
+	}
+
+	// Agora, tenta com o mesmo IP mas com token
+	token := "test-token"
+	tokenReq := &LimiterRequest{
+		IP:    ip,
+		Token: token,
+	}
+
+	// Deve permitir requisições até o limite do token, ignorando o limite do IP
+	for i := 0; i < cfg.TokenLimit; i++ {
+		if err := limiter.Allow(ctx, tokenReq); err != nil {
+			t.Errorf("deveria permitir requisição %d com token, mas recebeu erro: %v", i+1, err)
+		}
+	}
+
+	// A próxima requisição com token deve ser bloqueada
+	err = limiter.Allow(ctx, tokenReq)
+	if err == nil {
+		t.Error("deveria bloquear requisição acima do limite de token, mas permitiu")
+	}
+
+	// Verifica se o erro é do tipo correto e se o tipo de limite é TokenLimit
+	limitErr, ok := err.(*LimitExceededError)
+	if !ok || limitErr.Type != TokenLimit {
+		t.Errorf("erro deveria ser LimitExceededError do tipo TokenLimit")
+	}
+}
+
+func TestRateLimiter_BlockExpires(t *testing.T) {
+	// Cria configuração de teste
+	cfg := &LimiterConfig{
+		IPLimit:        2,
+		IPBlockTime:    5 * time.Minute,
+		TokenLimit:     5,
+		TokenBlockTime: 5 * time.Minute,
+	}
+
+	// Relógio falso compartilhado entre o rate limiter e o armazenamento
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	storeFactory := func() (store.RateLimiterStore, error) {
+		return store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), nil
+	}
+
+	// Cria rate limiter
+	limiter, err := NewRateLimiter(storeFactory, WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime), WithClock(fakeClock))
+	if err != nil {
+		t.Fatalf("falha ao criar rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	// Esgota o limite e provoca o bloqueio
+	for i := 0; i < cfg.IPLimit; i++ {
+		if err := limiter.Allow(ctx, req); err != nil {
+			t.Fatalf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	if err := limiter.Allow(ctx, req); err == nil {
+		t.Fatal("deveria bloquear requisição acima do limite, mas permitiu")
+	}
+
+	// Mesmo após o fim da janela de um segundo, o IP continua bloqueado
+	fakeClock.Advance(4 * time.Minute)
+	if err := limiter.Allow(ctx, req); err == nil {
+		t.Error("IP deveria continuar bloqueado antes do fim do tempo de bloqueio")
+	}
+
+	// Após o tempo de bloqueio, as requisições voltam a ser permitidas
+	fakeClock.Advance(time.Minute)
+	if err := limiter.Allow(ctx, req); err != nil {
+		t.Errorf("deveria permitir requisição após o fim do bloqueio, mas recebeu erro: %v", err)
+	}
+}
+
+func TestRateLimiter_WindowReset(t *testing.T) {
+	// Cria configuração de teste
+	cfg := &LimiterConfig{
+		IPLimit:        2,
+		IPBlockTime:    5 * time.Minute,
+		TokenLimit:     5,
+		TokenBlockTime: 5 * time.Minute,
+	}
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	storeFactory := func() (store.RateLimiterStore, error) {
+		return store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}), nil
+	}
+
+	limiter, err := NewRateLimiter(storeFactory, WithIPLimit(cfg.IPLimit, cfg.IPBlockTime), WithTokenLimit(cfg.TokenLimit, cfg.TokenBlockTime), WithClock(fakeClock))
+	if err != nil {
+		t.Fatalf("falha ao criar rate limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	// Requisições no limite em janelas consecutivas não causam bloqueio
+	for window := 0; window < 3; window++ {
+		for i := 0; i < cfg.IPLimit; i++ {
+			if err := limiter.Allow(ctx, req); err != nil {
+				t.Fatalf("janela %d: deveria permitir requisição %d, mas recebeu erro: %v", window, i+1, err)
+			}
+		}
+		fakeClock.Advance(time.Second)
+	}
+}
+
+func TestRateLimiter_WindowStartsAtFirstRequest(t *testing.T) {
+	// Começa perto do fim de um segundo do relógio
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 900_000_000, time.UTC))
+	limiter := New(
+		store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}),
+		WithIPLimit(2, 0),
+		WithRules(Rule{Name: "aligned", Limit: 2, Window: time.Second, Aligned: true}),
+		WithClock(fakeClock),
+	)
+	defer limiter.Close()
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+	check := &CheckRequest{Rule: "aligned", Descriptors: map[string]string{"ip": "192.168.1.1"}}
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(ctx, req); err != nil {
+			t.Fatalf("requisição %d deveria ser permitida: %v", i+1, err)
+		}
+		if decision, err := limiter.Check(ctx, check); err != nil || !decision.Allowed {
+			t.Fatalf("requisição %d deveria ser permitida pela regra alinhada (erro: %v)", i+1, err)
+		}
+	}
+
+	// Uma rajada que atravessa o segundo do relógio continua na mesma janela
+	fakeClock.Advance(200 * time.Millisecond)
+	decision, err := limiter.Decide(ctx, req)
+	if err == nil {
+		t.Error("rajada que atravessa o fim do segundo deveria ser negada")
+	}
+	if decision != nil && decision.Reset != 800*time.Millisecond {
+		t.Errorf("reset deveria ser 800ms, mas recebeu %v", decision.Reset)
+	}
+
+	// Com Aligned, a janela termina no segundo do relógio
+	decision, err = limiter.Check(ctx, check)
+	if err != nil {
+		t.Fatalf("erro ao verificar regra: %v", err)
+	}
+	if !decision.Allowed || decision.Reset != 900*time.Millisecond {
+		t.Errorf("regra alinhada deveria permitir com reset de 900ms, mas recebeu %+v", decision)
+	}
+}
+
+func TestNew_DefaultLimits(t *testing.T) {
+	// Sem opções, o RateLimiter usa os limites padrão
+	limiter := New(store.NewMemoryStore())
+	defer limiter.Close()
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1"}
+
+	for i := 0; i < DefaultIPLimit; i++ {
+		if err := limiter.Allow(ctx, req); err != nil {
+			t.Fatalf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	if err := limiter.Allow(ctx, req); err == nil {
+		t.Error("deveria bloquear requisição acima do limite padrão, mas permitiu")
+	}
+}
+
+func TestRateLimiter_Check(t *testing.T) {
+	rule := Rule{Name: "checkout", Limit: 5, Window: time.Minute}
+
+	limiter, fakeClock := newTestLimiter(t, WithRules(rule))
+
+	ctx := context.Background()
+	req := &CheckRequest{
+		Rule:        "checkout",
+		Descriptors: map[string]string{"user": "42"},
+		Cost:        2,
+	}
+
+	// Duas requisições de custo 2 consomem 4 das 5 unidades da janela
+	for i := 0; i < 2; i++ {
+		decision, err := limiter.Check(ctx, req)
+		if err != nil {
+			t.Fatalf("erro ao verificar regra: %v", err)
+		}
+		if !decision.Allowed {
+			t.Fatalf("deveria permitir requisição %d", i+1)
+		}
+		if want := rule.Limit - 2*(i+1); decision.Remaining != want {
+			t.Errorf("restante deveria ser %d, mas recebeu %d", want, decision.Remaining)
+		}
+		if decision.Reset != time.Minute {
+			t.Errorf("reset deveria ser %v, mas recebeu %v", time.Minute, decision.Reset)
+		}
+	}
+
+	// A terceira excede o limite e é negada até o fim da janela, sem bloqueio
+	fakeClock.Advance(15 * time.Second)
+	decision, err := limiter.Check(ctx, req)
+	if err != nil {
+		t.Fatalf("erro ao verificar regra: %v", err)
+	}
+	if decision.Allowed {
+		t.Fatal("deveria negar requisição acima do limite")
+	}
+	if decision.Reset != 45*time.Second {
+		t.Errorf("reset deveria ser %v, mas recebeu %v", 45*time.Second, decision.Reset)
+	}
+
+	// Outros descritores têm seu próprio contador
+	other := &CheckRequest{Rule: "checkout", Descriptors: map[string]string{"user": "7"}}
+	if decision, err := limiter.Check(ctx, other); err != nil || !decision.Allowed {
+		t.Errorf("deveria permitir requisição de outro usuário, mas recebeu %+v, %v", decision, err)
+	}
+
+	// Na próxima janela o contador recomeça
+	fakeClock.Advance(45 * time.Second)
+	if decision, err := limiter.Check(ctx, req); err != nil || !decision.Allowed {
+		t.Errorf("deveria permitir requisição na nova janela, mas recebeu %+v, %v", decision, err)
+	}
+}
+
+func TestRateLimiter_CheckBlock(t *testing.T) {
+	rule := Rule{Name: "login", Limit: 1, Window: time.Second, BlockTime: time.Minute}
+
+	limiter, fakeClock := newTestLimiter(t, WithRules(rule))
+
+	ctx := context.Background()
+	req := &CheckRequest{Rule: "login", Descriptors: map[string]string{"ip": "10.0.0.1"}}
+
+	if _, err := limiter.Check(ctx, req); err != nil {
+		t.Fatalf("erro ao verificar regra: %v", err)
+	}
+
+	// Excede o limite e bloqueia pelo tempo da regra
+	decision, err := limiter.Check(ctx, req)
+	if err != nil {
+		t.Fatalf("erro ao verificar regra: %v", err)
+	}
+	if decision.Allowed || decision.Reset != time.Minute {
+		t.Errorf("deveria negar com reset de %v, mas recebeu %+v", time.Minute, decision)
+	}
+
+	// O reset reflete o tempo restante do bloqueio
+	fakeClock.Advance(20 * time.Second)
+	decision, err = limiter.Check(ctx, req)
+	if err != nil {
+		t.Fatalf("erro ao verificar regra: %v", err)
+	}
+	if decision.Allowed || decision.Reset != 40*time.Second {
+		t.Errorf("deveria negar com reset de %v, mas recebeu %+v", 40*time.Second, decision)
+	}
+}
+
+func TestRateLimiter_CheckErrors(t *testing.T) {
+	limiter := New(store.NewMemoryStore())
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	// Regra inexistente
+	_, err := limiter.Check(ctx, &CheckRequest{Rule: "missing"})
+	if !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("erro deveria ser ErrUnknownRule, mas recebeu: %v", err)
+	}
+
+	// Custo negativo
+	_, err = limiter.Check(ctx, &CheckRequest{Rule: IPRuleName, Cost: -1})
+	if !errors.Is(err, ErrInvalidCost) {
+		t.Errorf("erro deveria ser ErrInvalidCost, mas recebeu: %v", err)
+	}
+
+	// As regras internas de IP e token podem ser consultadas pelo nome
+	decision, err := limiter.Check(ctx, &CheckRequest{Rule: TokenRuleName, Descriptors: map[string]string{"token": "abc"}})
+	if err != nil {
+		t.Fatalf("erro ao verificar regra interna: %v", err)
+	}
+	if decision.Limit != DefaultTokenLimit {
+		t.Errorf("limite deveria ser %d, mas recebeu %d", DefaultTokenLimit, decision.Limit)
+	}
+}
+
+func TestDescriptorKey_NoCollisions(t *testing.T) {
+	if key := descriptorKey("checkout", map[string]string{"user": "42", "path": "/a"}); key != "rule:checkout:path=/a:user=42" {
+		t.Errorf("chave inesperada: %s", key)
+	}
+
+	// Valores com separadores não podem formar a chave de outra combinação
+	sets := []struct {
+		rule        string
+		descriptors map[string]string
+	}{
+		{"checkout", map[string]string{"a": "1", "b": "2"}},
+		{"checkout", map[string]string{"a": "1:b=2"}},
+		{"checkout", map[string]string{"a=1:b": "2"}},
+		{"checkout", map[string]string{"a": "1%3Ab%3D2"}},
+		{"checkout:a=1", map[string]string{"b": "2"}},
+	}
+	seen := make(map[string]int)
+	for i, set := range sets {
+		key := descriptorKey(set.rule, set.descriptors)
+		if j, ok := seen[key]; ok {
+			t.Errorf("combinações %d e %d geraram a mesma chave %s", j, i, key)
+		}
+		seen[key] = i
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`{"rules": [
+		{"name": "checkout", "limit": 100, "window": "1m", "block_time": "5m"},
+		{"name": "search", "limit": 10, "aligned": true}
+	]}`))
+	if err != nil {
+		t.Fatalf("erro ao interpretar regras: %v", err)
+	}
+
+	want := []Rule{
+		{Name: "checkout", Limit: 100, Window: time.Minute, BlockTime: 5 * time.Minute},
+		{Name: "search", Limit: 10, Aligned: true},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("deveria interpretar %d regras, mas recebeu %d", len(want), len(rules))
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("regra %d deveria ser %+v, mas recebeu %+v", i, want[i], rules[i])
+		}
+	}
+
+	// Arquivos inválidos
+	invalid := []string{
+		`{"rules": [{"limit": 1}]}`,
+		`{"rules": [{"name": "a", "limit": 0}]}`,
+		`{"rules": [{"name": "a", "limit": 1, "window": "abc"}]}`,
+		`{"rules": [{"name": "a", "limit": 1}, {"name": "a", "limit": 2}]}`,
+		`{"rules": [{"name": "ip", "limit": 1}]}`,
+		`{"rules": `,
+	}
+	for _, data := range invalid {
+		if _, err := ParseRules([]byte(data)); err == nil {
+			t.Errorf("deveria rejeitar o arquivo de regras %s", data)
+		}
+	}
+}
+
+func TestRateLimiter_Cost(t *testing.T) {
+	limiter, _ := newTestLimiter(t, WithTokenLimit(10, time.Minute))
+
+	ctx := context.Background()
+
+	// Uma requisição de custo 8 deixa apenas 2 unidades na janela
+	decision, err := limiter.Decide(ctx, &LimiterRequest{Token: "abc", Cost: 8})
+	if err != nil {
+		t.Fatalf("deveria permitir requisição de custo 8, mas recebeu erro: %v", err)
+	}
+	if decision.Remaining != 2 {
+		t.Errorf("restante deveria ser 2, mas recebeu %d", decision.Remaining)
+	}
+
+	// Uma requisição de custo 3 excede o limite
+	if err := limiter.Allow(ctx, &LimiterRequest{Token: "abc", Cost: 3}); err == nil {
+		t.Error("deveria bloquear requisição que excede o saldo, mas permitiu")
+	}
+
+	// Custo negativo é inválido
+	if err := limiter.Allow(ctx, &LimiterRequest{Token: "def", Cost: -1}); !errors.Is(err, ErrInvalidCost) {
+		t.Errorf("erro deveria ser ErrInvalidCost, mas recebeu: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package ratelimiter
+
+import (
+	"errors"
+	"time"
+)
+
+// LimiterConfig armazena a configuração do rate limiter
+type LimiterConfig struct {
+	// Limite de requisições por segundo por IP
+	IPLimit int
+	// Tempo de bloqueio para IP excedido
+	IPBlockTime time.Duration
+
+	// Limite de requisições por segundo por Token
+	TokenLimit int
+	// Tempo de bloqueio para Token excedido
+	TokenBlockTime time.Duration
+
+	// Máximo de requisições simultâneas por IP (0 desativa)
+	IPConcurrency int
+	// Máximo de requisições simultâneas por Token (0 desativa)
+	TokenConcurrency int
+	// Tempo após o qual uma vaga não liberada expira
+	ConcurrencyLeaseTTL time.Duration
+}
+
+// LimitType define o tipo de limitação (IP ou Token)
+type LimitType int
+
+const (
+	// IPLimit representa limitação por IP
+	IPLimit LimitType = iota
+	// TokenLimit representa limitação por Token
+	TokenLimit
+)
+
+// LimiterRequest representa uma requisição ao rate limiter
+type LimiterRequest struct {
+	// IP do cliente
+	IP string
+	// Token de acesso (opcional)
+	Token string
+	// Quanto a requisição consome do limite (0 equivale a 1)
+	Cost int
+	// Caminho da requisição, usado para selecionar os limites globais (opcional)
+	Path string
+	// Classe de prioridade da requisição nos limites globais (PriorityDefault usa
+	// PriorityNormal com token e PriorityLow sem token)
+	Priority Priority
+}
+
+// ClientKey retorna a chave do cliente da requisição: "token:<token>" quando há token,
+// que tem prioridade sobre o IP, ou "ip:<ip>"
+func (r *LimiterRequest) ClientKey() string {
+	if r.Token != "" {
+		return "token:" + r.Token
+	}
+	return "ip:" + r.IP
+}
+
+// CheckRequest representa uma consulta a uma regra nomeada
+type CheckRequest struct {
+	// Nome da regra a aplicar
+	Rule string
+	// Descritores que identificam o cliente (por exemplo, user=123, path=/checkout)
+	Descriptors map[string]string
+	// Quanto a requisição consome do limite (0 equivale a 1)
+	Cost int
+}
+
+// Decision é o resultado da avaliação de uma requisição
+type Decision struct {
+	// Indica se a requisição foi permitida
+	Allowed bool
+	// Nome da regra aplicada
+	Rule string
+	// Chave avaliada no armazenamento
+	Key string
+	// Limite de requisições por janela
+	Limit int
+	// Período do agendamento que definiu o limite ("" quando vale o limite estático)
+	Schedule string
+	// Duração da janela da regra
+	Window time.Duration
+	// Requisições restantes na janela atual
+	Remaining int
+	// Tempo até o fim da janela atual ou, se a chave está bloqueada, até o fim do bloqueio.
+	// Quando uma cota foi excedida, tempo até o início do próximo período da cota.
+	Reset time.Duration
+	// Consumo das cotas de longo prazo (apenas em Decide, para requisições dentro do limite)
+	Quotas []QuotaUsage
+}
+
+var (
+	// ErrUnknownRule é retornado quando a regra consultada não existe
+	ErrUnknownRule = errors.New("regra desconhecida")
+	// ErrInvalidCost é retornado quando o custo da requisição é negativo
+	ErrInvalidCost = errors.New("custo inválido")
+)
+
+// LimitExceededError é retornado quando o limite é excedido
+type LimitExceededError struct {
+	// Tipo de limitação que foi excedida
+	Type LimitType
+	// Mensagem de erro
+	Message string
+	// Tempo até que novas requisições sejam aceitas
+	RetryAfter time.Duration
+}
+
+// Error implementa a interface error
+func (e *LimitExceededError) Error() string {
+	return e.Message
+}
+
+// NewLimitExceededError cria um novo erro de limite excedido
+func NewLimitExceededError(limitType LimitType) *LimitExceededError {
+	return &LimitExceededError{
+		Type:    limitType,
+		Message: "you have reached the maximum number of requests or actions allowed within a certain time frame",
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimiter
+
+import (
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+)
+
+// Limites usados quando nenhuma opção é informada
+const (
+	DefaultIPLimit        = 5
+	DefaultIPBlockTime    = 5 * time.Minute
+	DefaultTokenLimit     = 10
+	DefaultTokenBlockTime = 5 * time.Minute
+)
+
+// Option configura parâmetros opcionais do RateLimiter
+type Option func(*RateLimiter)
+
+// WithIPLimit define o limite de requisições por segundo por IP e o tempo de bloqueio ao excedê-lo
+func WithIPLimit(limit int, blockTime time.Duration) Option {
+	return func(rl *RateLimiter) {
+		rl.config.IPLimit = limit
+		rl.config.IPBlockTime = blockTime
+	}
+}
+
+// WithTokenLimit define o limite de requisições por segundo por token e o tempo de bloqueio ao excedê-lo
+func WithTokenLimit(limit int, blockTime time.Duration) Option {
+	return func(rl *RateLimiter) {
+		rl.config.TokenLimit = limit
+		rl.config.TokenBlockTime = blockTime
+	}
+}
+
+// WithConcurrencyLimit limita o número de requisições simultâneas por IP e por token
+// (0 desativa). leaseTTL é o tempo após o qual uma vaga não liberada expira; deve ser
+// maior que a duração da requisição mais longa. Requer um armazenamento que implemente
+// store.ConcurrencyStore.
+func WithConcurrencyLimit(ipLimit, tokenLimit int, leaseTTL time.Duration) Option {
+	return func(rl *RateLimiter) {
+		rl.config.IPConcurrency = ipLimit
+		rl.config.TokenConcurrency = tokenLimit
+		if leaseTTL > 0 {
+			rl.config.ConcurrencyLeaseTTL = leaseTTL
+		}
+	}
+}
+
+// WithAdaptiveLimit ajusta automaticamente o limite de uma regra (IPRuleName, TokenRuleName
+// ou uma regra nomeada) conforme a latência e os erros observados no backend. O limite
+// parte do valor estático da regra e varia entre cfg.Floor e cfg.Ceiling. As observações
+// são enviadas com Observe, como faz o middleware HTTP.
+func WithAdaptiveLimit(rule string, cfg AdaptiveConfig) Option {
+	return func(rl *RateLimiter) {
+		rl.adaptive[rule] = newAdaptiveLimit(cfg)
+	}
+}
+
+// WithSchedule define limites por horário do dia e dia da semana para uma regra
+// (IPRuleName, TokenRuleName ou uma regra nomeada). Durante cada período, o limite do
+// período substitui o estático, e Decision.Schedule informa o período em vigor. Para
+// regras nomeadas, tem precedência sobre Rule.Schedule.
+func WithSchedule(rule string, schedule *Schedule) Option {
+	return func(rl *RateLimiter) {
+		rl.schedules[rule] = schedule
+	}
+}
+
+// WithBandwidthLimit limita os bytes por segundo das chaves de um tipo de limitação. O
+// middleware HTTP mede os corpos da requisição e da resposta e atrasa a transferência
+// quando o balde se esgota, em vez de rejeitar. Requer um armazenamento que implemente
+// store.TokenBucketStore.
+func WithBandwidthLimit(limitType LimitType, limit BandwidthLimit) Option {
+	return func(rl *RateLimiter) {
+		rl.bandwidth[limitType] = limit
+	}
+}
+
+// WithRules registra regras nomeadas, consultadas por Check
+func WithRules(rules ...Rule) Option {
+	return func(rl *RateLimiter) {
+		for _, rule := range rules {
+			rl.rules[rule.Name] = rule
+		}
+	}
+}
+
+// WithGlobalLimits registra limites agregados, compartilhados por todos os clientes.
+// Os limites são selecionados pelo prefixo de LimiterRequest.Path e verificados por
+// Decide e Allow depois do limite do cliente.
+func WithGlobalLimits(limits ...GlobalLimit) Option {
+	return func(rl *RateLimiter) {
+		rl.globals = append(rl.globals, limits...)
+	}
+}
+
+// WithSheddingPolicy reserva parte da capacidade dos limites globais para as classes de
+// prioridade mais altas, descartando primeiro o tráfego de menor prioridade
+func WithSheddingPolicy(policy SheddingPolicy) Option {
+	return func(rl *RateLimiter) {
+		rl.shedding = policy
+	}
+}
+
+// WithQuotas aplica cotas de longo prazo às chaves de um tipo de limitação (por exemplo,
+// TokenLimit para cotas de planos pagos). As cotas são verificadas por Decide e Allow
+// depois do limite por segundo.
+func WithQuotas(limitType LimitType, quotas ...Quota) Option {
+	return func(rl *RateLimiter) {
+		rl.quotas[limitType] = append(rl.quotas[limitType], quotas...)
+	}
+}
+
+// WithQuotaLocation define o fuso horário usado para alinhar os períodos das cotas ao
+// calendário (padrão UTC)
+func WithQuotaLocation(loc *time.Location) Option {
+	return func(rl *RateLimiter) {
+		if loc != nil {
+			rl.location = loc
+		}
+	}
+}
+
+// WithEvents publica no barramento os eventos de limite excedido, bloqueio e fim de
+// bloqueio das chaves. O barramento não é fechado por Close.
+func WithEvents(bus *EventBus) Option {
+	return func(rl *RateLimiter) {
+		rl.events = bus
+	}
+}
+
+// WithClock define o relógio usado pelo RateLimiter nos cálculos de tempo.
+// Em testes, use o mesmo clock.Fake no RateLimiter e no armazenamento.
+func WithClock(c clock.Clock) Option {
+	return func(rl *RateLimiter) {
+		rl.clock = clock.OrDefault(c)
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Priority é a classe de prioridade de uma requisição, usada para descartar primeiro o
+// tráfego menos importante quando um limite global se aproxima da capacidade
+type Priority int
+
+const (
+	// PriorityDefault deixa o rate limiter escolher: PriorityNormal para requisições com
+	// token e PriorityLow para as anônimas
+	PriorityDefault Priority = iota
+	// PriorityLow é a primeira classe descartada (por exemplo, tráfego anônimo)
+	PriorityLow
+	// PriorityNormal é a classe dos clientes autenticados
+	PriorityNormal
+	// PriorityHigh é a última classe descartada (por exemplo, clientes pagantes)
+	PriorityHigh
+)
+
+// ParsePriority converte "low", "normal" ou "high" em uma Priority
+func ParsePriority(value string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "low":
+		return PriorityLow, nil
+	case "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityDefault, fmt.Errorf("prioridade inválida: %s", value)
+}
+
+// String retorna o nome da prioridade
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return "default"
+}
+
+// SheddingPolicy reserva parcelas da capacidade dos limites globais para as classes de
+// prioridade mais altas. Uma requisição só usa a capacidade que não está reservada para
+// classes acima da sua: com Reserved{PriorityHigh: 0.3, PriorityNormal: 0.2}, o tráfego
+// de baixa prioridade é descartado a partir de 50% do limite, o normal a partir de 70%
+// e o de alta prioridade apenas no limite.
+type SheddingPolicy struct {
+	// Fração de cada limite global reservada para cada classe
+	Reserved map[Priority]float64
+}
+
+// admitted retorna quanto de um limite global a classe pode usar
+func (p SheddingPolicy) admitted(priority Priority, limit int) int {
+	reserved := 0.0
+	for class, share := range p.Reserved {
+		if class > priority {
+			reserved += share
+		}
+	}
+	if reserved <= 0 {
+		return limit
+	}
+	if reserved >= 1 {
+		return 0
+	}
+	return int(float64(limit) * (1 - reserved))
+}
+
+// ParseSheddingPolicy converte uma lista no formato high=0.3,normal=0.2 em uma política
+// de descarte
+func ParseSheddingPolicy(value string) (SheddingPolicy, error) {
+	policy := SheddingPolicy{Reserved: make(map[Priority]float64)}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, share, ok := strings.Cut(entry, "=")
+		if !ok {
+			return SheddingPolicy{}, fmt.Errorf("reserva inválida: %s", entry)
+		}
+		priority, err := ParsePriority(name)
+		if err != nil {
+			return SheddingPolicy{}, err
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(share), 64)
+		if err != nil || f < 0 || f > 1 {
+			return SheddingPolicy{}, fmt.Errorf("reserva inválida para %s: %s", name, share)
+		}
+		policy.Reserved[priority] = f
+	}
+
+	return policy, nil
+}
+
+// requestPriority resolve a prioridade da requisição, aplicando o padrão por tipo de chave
+func requestPriority(req *LimiterRequest) Priority {
+	if req.Priority != PriorityDefault {
+		return req.Priority
+	}
+	if req.Token != "" {
+		return PriorityNormal
+	}
+	return PriorityLow
+}
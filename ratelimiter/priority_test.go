@@ -0,0 +1,82 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRateLimiter_SheddingPolicy(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		WithIPLimit(100, 0),
+		WithTokenLimit(100, 0),
+		WithGlobalLimits(GlobalLimit{Name: "api", Limit: 10}),
+		// Baixa prioridade usa até 50% do limite, normal até 70% e alta até 100%
+		WithSheddingPolicy(SheddingPolicy{Reserved: map[Priority]float64{PriorityHigh: 0.3, PriorityNormal: 0.2}}),
+	)
+
+	ctx := context.Background()
+
+	// Requisições anônimas são de baixa prioridade e descartadas a partir de 5 no total
+	for i := 0; i < 5; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.1", Path: "/"}); err != nil {
+			t.Fatalf("deveria permitir requisição anônima %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	err := limiter.Allow(ctx, &LimiterRequest{IP: "10.0.0.2", Path: "/"})
+	var globalErr *GlobalLimitExceededError
+	if !errors.As(err, &globalErr) || !globalErr.Shed || globalErr.Priority != PriorityLow {
+		t.Fatalf("requisição anônima deveria ser descartada, mas recebeu: %v", err)
+	}
+
+	// Requisições com token são normais e usam até 7
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{Token: "abc", Path: "/"}); err != nil {
+			t.Fatalf("deveria permitir requisição normal %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	if err := limiter.Allow(ctx, &LimiterRequest{Token: "abc", Path: "/"}); !errors.As(err, &globalErr) || !globalErr.Shed {
+		t.Fatalf("requisição normal deveria ser descartada, mas recebeu: %v", err)
+	}
+
+	// Alta prioridade usa a capacidade reservada até o limite total
+	for i := 0; i < 3; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{Token: "vip", Path: "/", Priority: PriorityHigh}); err != nil {
+			t.Fatalf("deveria permitir requisição de alta prioridade %d, mas recebeu erro: %v", i+1, err)
+		}
+	}
+	err = limiter.Allow(ctx, &LimiterRequest{Token: "vip", Path: "/", Priority: PriorityHigh})
+	if !errors.As(err, &globalErr) || globalErr.Shed {
+		t.Errorf("requisição de alta prioridade deveria ser negada pelo limite total, mas recebeu: %v", err)
+	}
+}
+
+func TestParseSheddingPolicy(t *testing.T) {
+	policy, err := ParseSheddingPolicy("high=0.3, normal=0.2")
+	if err != nil {
+		t.Fatalf("erro ao interpretar política: %v", err)
+	}
+	if policy.Reserved[PriorityHigh] != 0.3 || policy.Reserved[PriorityNormal] != 0.2 {
+		t.Errorf("reservas inesperadas: %v", policy.Reserved)
+	}
+
+	tests := []struct {
+		priority Priority
+		want     int
+	}{
+		{PriorityLow, 500},
+		{PriorityNormal, 700},
+		{PriorityHigh, 1000},
+	}
+	for _, tt := range tests {
+		if got := policy.admitted(tt.priority, 1000); got != tt.want {
+			t.Errorf("capacidade de %s deveria ser %d, mas recebeu %d", tt.priority, tt.want, got)
+		}
+	}
+
+	for _, value := range []string{"urgent=0.1", "high", "high=1.5", "high=abc"} {
+		if _, err := ParseSheddingPolicy(value); err == nil {
+			t.Errorf("deveria rejeitar %q", value)
+		}
+	}
+}
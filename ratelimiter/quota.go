@@ -0,0 +1,184 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// QuotaPeriod define o período de calendário de uma cota
+type QuotaPeriod int
+
+const (
+	// QuotaHourly reinicia no início de cada hora
+	QuotaHourly QuotaPeriod = iota
+	// QuotaDaily reinicia à meia-noite
+	QuotaDaily
+	// QuotaMonthly reinicia no primeiro dia de cada mês
+	QuotaMonthly
+)
+
+// ParseQuotaPeriod converte "hour", "day" ou "month" em um QuotaPeriod
+func ParseQuotaPeriod(value string) (QuotaPeriod, error) {
+	switch value {
+	case "hour":
+		return QuotaHourly, nil
+	case "day":
+		return QuotaDaily, nil
+	case "month":
+		return QuotaMonthly, nil
+	}
+	return 0, fmt.Errorf("período de cota inválido: %s", value)
+}
+
+// bounds retorna o início e o fim do período que contém now, no fuso horário informado
+func (p QuotaPeriod) bounds(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	t := now.In(loc)
+	switch p {
+	case QuotaHourly:
+		start := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		return start, start.Add(time.Hour)
+	case QuotaDaily:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 1)
+	default:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// Quota define um limite de longo prazo com reinício alinhado ao calendário
+type Quota struct {
+	// Nome da cota (por exemplo, "monthly")
+	Name string
+	// Número máximo de requisições (ou custo acumulado) no período
+	Limit int
+	// Período de calendário da cota
+	Period QuotaPeriod
+}
+
+// QuotaUsage representa o consumo de uma cota no período atual
+type QuotaUsage struct {
+	// Nome da cota
+	Name string
+	// Limite do período
+	Limit int
+	// Requisições restantes no período
+	Remaining int
+	// Tempo até o início do próximo período
+	Reset time.Duration
+}
+
+// QuotaExceededError é retornado quando uma cota de longo prazo é excedida. É distinto de
+// LimitExceededError: a requisição respeitou o limite por segundo, mas esgotou a cota.
+type QuotaExceededError struct {
+	// Tipo de limitação (IP ou token) da chave
+	Type LimitType
+	// Nome da cota excedida
+	Quota string
+	// Mensagem de erro
+	Message string
+	// Tempo até o início do próximo período
+	RetryAfter time.Duration
+}
+
+// Error implementa a interface error
+func (e *QuotaExceededError) Error() string {
+	return e.Message
+}
+
+// NewQuotaExceededError cria um novo erro de cota excedida
+func NewQuotaExceededError(limitType LimitType, quota string) *QuotaExceededError {
+	return &QuotaExceededError{
+		Type:    limitType,
+		Quota:   quota,
+		Message: fmt.Sprintf("you have exhausted your %s quota", quota),
+	}
+}
+
+// IsRejected indica se o erro é uma negação do rate limiter (limite, limite global, cota
+// ou concorrência excedidos), e não uma falha do armazenamento
+func IsRejected(err error) bool {
+	var limitErr *LimitExceededError
+	var globalErr *GlobalLimitExceededError
+	var quotaErr *QuotaExceededError
+	var concurrencyErr *ConcurrencyExceededError
+	return errors.As(err, &limitErr) || errors.As(err, &globalErr) ||
+		errors.As(err, &quotaErr) || errors.As(err, &concurrencyErr)
+}
+
+// QuotaHeaders retorna os cabeçalhos X-Quota-Limit, X-Quota-Remaining e X-Quota-Reset
+// (segundos) da cota mais próxima de se esgotar. Retorna nil se a decisão não tem cotas.
+func QuotaHeaders(decision *Decision) map[string]string {
+	if decision == nil || len(decision.Quotas) == 0 {
+		return nil
+	}
+
+	tightest := decision.Quotas[0]
+	for _, usage := range decision.Quotas[1:] {
+		if usage.Remaining < tightest.Remaining {
+			tightest = usage
+		}
+	}
+
+	return map[string]string{
+		"X-Quota-Limit":     strconv.Itoa(tightest.Limit),
+		"X-Quota-Remaining": strconv.Itoa(tightest.Remaining),
+		"X-Quota-Reset":     strconv.FormatInt(int64(math.Ceil(tightest.Reset.Seconds())), 10),
+	}
+}
+
+// consumeQuotas soma o custo às cotas do tipo de limitação e retorna o consumo de cada
+// uma. Os contadores ficam no armazenamento e expiram no fim do período; os incrementos
+// são acrescentados a charges para que Decide possa devolvê-los.
+func (rl *RateLimiter) consumeQuotas(ctx context.Context, limitType LimitType, key string, cost int, charges *[]charge) ([]QuotaUsage, *QuotaExceededError, error) {
+	quotas := rl.quotas[limitType]
+	if len(quotas) == 0 {
+		return nil, nil, nil
+	}
+
+	now := rl.clock.Now()
+	usages := make([]QuotaUsage, 0, len(quotas))
+	counts := make([]int, 0, len(quotas))
+	var exceeded *QuotaExceededError
+
+	for _, quota := range quotas {
+		start, end := quota.Period.bounds(now, rl.location)
+		reset := end.Sub(now)
+
+		quotaKey := fmt.Sprintf("%s%s:%s:%d", store.QuotaKeyPrefix, quota.Name, key, start.Unix())
+		count, err := rl.store.IncrementRequestCountBy(ctx, quotaKey, cost, reset)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao verificar cota %s: %w", quota.Name, err)
+		}
+		*charges = append(*charges, charge{key: quotaKey, cost: cost, expiration: reset})
+		counts = append(counts, count)
+
+		usages = append(usages, QuotaUsage{
+			Name:      quota.Name,
+			Limit:     quota.Limit,
+			Remaining: max(quota.Limit-count, 0),
+			Reset:     reset,
+		})
+
+		// Entre várias cotas excedidas, informa a que demora mais para reiniciar
+		if count > quota.Limit && (exceeded == nil || reset > exceeded.RetryAfter) {
+			exceeded = NewQuotaExceededError(limitType, quota.Name)
+			exceeded.RetryAfter = reset
+		}
+	}
+
+	// A requisição negada é devolvida por Decide, então não consome nenhuma das cotas
+	if exceeded != nil {
+		for i := range usages {
+			usages[i].Remaining = max(usages[i].Limit-(counts[i]-cost), 0)
+		}
+	}
+
+	return usages, exceeded, nil
+}
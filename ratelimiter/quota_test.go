@@ -0,0 +1,175 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+func TestQuotaPeriod_Bounds(t *testing.T) {
+	// Fuso fixo de -3h para não depender da base de fusos do sistema
+	loc := time.FixedZone("BRT", -3*60*60)
+
+	// 2025-01-31 23:30 em UTC já é 2025-01-31 20:30 no fuso local
+	now := time.Date(2025, 1, 31, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period QuotaPeriod
+		start  time.Time
+		end    time.Time
+	}{
+		{QuotaHourly, time.Date(2025, 1, 31, 20, 0, 0, 0, loc), time.Date(2025, 1, 31, 21, 0, 0, 0, loc)},
+		{QuotaDaily, time.Date(2025, 1, 31, 0, 0, 0, 0, loc), time.Date(2025, 2, 1, 0, 0, 0, 0, loc)},
+		{QuotaMonthly, time.Date(2025, 1, 1, 0, 0, 0, 0, loc), time.Date(2025, 2, 1, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		start, end := tt.period.bounds(now, loc)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("período %d deveria ser [%v, %v), mas recebeu [%v, %v)", tt.period, tt.start, tt.end, start, end)
+		}
+	}
+}
+
+func TestParseQuotaPeriod(t *testing.T) {
+	for value, want := range map[string]QuotaPeriod{"hour": QuotaHourly, "day": QuotaDaily, "month": QuotaMonthly} {
+		got, err := ParseQuotaPeriod(value)
+		if err != nil || got != want {
+			t.Errorf("%s deveria ser %d, mas recebeu %d, %v", value, want, got, err)
+		}
+	}
+
+	if _, err := ParseQuotaPeriod("week"); err == nil {
+		t.Error("deveria rejeitar período desconhecido")
+	}
+}
+
+func TestRateLimiter_Quota(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC))
+	limiter := New(store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock}),
+		WithTokenLimit(2, time.Minute),
+		WithQuotas(TokenLimit, Quota{Name: "monthly", Limit: 3, Period: QuotaMonthly}),
+		WithClock(fakeClock),
+	)
+	defer limiter.Close()
+
+	ctx := context.Background()
+	req := &LimiterRequest{Token: "abc"}
+
+	// Requisições em segundos diferentes, dentro do limite por segundo
+	for i := 0; i < 3; i++ {
+		decision, err := limiter.Decide(ctx, req)
+		if err != nil {
+			t.Fatalf("deveria permitir requisição %d, mas recebeu erro: %v", i+1, err)
+		}
+		if len(decision.Quotas) != 1 || decision.Quotas[0].Remaining != 2-i {
+			t.Errorf("cota restante deveria ser %d, mas recebeu %+v", 2-i, decision.Quotas)
+		}
+		fakeClock.Advance(time.Second)
+	}
+
+	// A quarta esgota a cota: o erro é distinto do limite por segundo
+	decision, err := limiter.Decide(ctx, req)
+	var quotaErr *QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("erro deveria ser QuotaExceededError, mas recebeu: %v", err)
+	}
+	var limitErr *LimitExceededError
+	if errors.As(err, &limitErr) {
+		t.Error("cota excedida não deveria ser LimitExceededError")
+	}
+	if !IsRejected(err) {
+		t.Error("cota excedida deveria ser uma negação")
+	}
+
+	// O reinício é no primeiro dia do mês seguinte
+	wantReset := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC).Sub(fakeClock.Now())
+	if quotaErr.RetryAfter != wantReset || decision.Reset != wantReset {
+		t.Errorf("reset deveria ser %v, mas recebeu %v e %v", wantReset, quotaErr.RetryAfter, decision.Reset)
+	}
+
+	// No mês seguinte a cota é renovada
+	fakeClock.Advance(wantReset)
+	if err := limiter.Allow(ctx, req); err != nil {
+		t.Errorf("deveria permitir requisição no novo período, mas recebeu erro: %v", err)
+	}
+
+	// Cotas de token não se aplicam a requisições por IP
+	for i := 0; i < 4; i++ {
+		if err := limiter.Allow(ctx, &LimiterRequest{IP: "192.168.1.1"}); err != nil {
+			t.Fatalf("deveria permitir requisição %d por IP, mas recebeu erro: %v", i+1, err)
+		}
+		fakeClock.Advance(time.Second)
+	}
+}
+
+func TestRateLimiter_QuotaNotConsumedWhenRateLimited(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(t,
+		WithTokenLimit(1, 0),
+		WithQuotas(TokenLimit, Quota{Name: "daily", Limit: 10, Period: QuotaDaily}),
+	)
+
+	ctx := context.Background()
+	req := &LimiterRequest{Token: "abc"}
+
+	// A segunda requisição do mesmo segundo é negada pelo limite por segundo
+	if err := limiter.Allow(ctx, req); err != nil {
+		t.Fatalf("deveria permitir primeira requisição, mas recebeu erro: %v", err)
+	}
+	var limitErr *LimitExceededError
+	if err := limiter.Allow(ctx, req); !errors.As(err, &limitErr) {
+		t.Fatalf("erro deveria ser LimitExceededError, mas recebeu: %v", err)
+	}
+
+	// Apenas a requisição permitida consumiu a cota
+	fakeClock.Advance(time.Second)
+	decision, err := limiter.Decide(ctx, req)
+	if err != nil {
+		t.Fatalf("deveria permitir requisição, mas recebeu erro: %v", err)
+	}
+	if got := decision.Quotas[0].Remaining; got != 8 {
+		t.Errorf("cota restante deveria ser 8, mas recebeu %d", got)
+	}
+
+	headers := QuotaHeaders(decision)
+	if headers["X-Quota-Remaining"] != "8" || headers["X-Quota-Limit"] != "10" {
+		t.Errorf("cabeçalhos de cota inesperados: %v", headers)
+	}
+}
+
+func TestRateLimiter_QuotaDenialRefundsCounters(t *testing.T) {
+	limiter, _ := newTestLimiter(t,
+		WithTokenLimit(5, 0),
+		WithQuotas(TokenLimit,
+			Quota{Name: "hourly", Limit: 10, Period: QuotaHourly},
+			Quota{Name: "daily", Limit: 1, Period: QuotaDaily},
+		),
+	)
+
+	ctx := context.Background()
+	req := &LimiterRequest{Token: "abc"}
+
+	if err := limiter.Allow(ctx, req); err != nil {
+		t.Fatalf("deveria permitir primeira requisição, mas recebeu erro: %v", err)
+	}
+
+	// As requisições negadas pela cota diária não consomem o limite por segundo nem a
+	// cota horária
+	for i := 0; i < 3; i++ {
+		decision, err := limiter.Decide(ctx, req)
+		var quotaErr *QuotaExceededError
+		if !errors.As(err, &quotaErr) || quotaErr.Quota != "daily" {
+			t.Fatalf("erro deveria indicar a cota diária, mas recebeu: %v", err)
+		}
+		if decision.Remaining != 4 {
+			t.Errorf("saldo do limite por segundo deveria ser 4, mas recebeu %d", decision.Remaining)
+		}
+		if got := decision.Quotas[0].Remaining; got != 9 {
+			t.Errorf("cota horária restante deveria ser 9, mas recebeu %d", got)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package ratelimiter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Nomes das regras internas, derivadas de LimiterConfig
+const (
+	IPRuleName    = "ip"
+	TokenRuleName = "token"
+)
+
+// Rule define um limite nomeado, aplicado a chaves formadas por descritores
+type Rule struct {
+	// Nome único da regra
+	Name string
+	// Número máximo de requisições (ou custo acumulado) por janela
+	Limit int
+	// Duração da janela (0 equivale a um segundo). A janela começa na primeira requisição
+	// da chave, a menos que Aligned esteja ativo.
+	Window time.Duration
+	// Usa janelas fixas alinhadas ao relógio (por exemplo, de 12:00:00 a 12:01:00 em
+	// janelas de um minuto), com um contador por janela. Uma rajada que atravessa o fim
+	// de uma janela pode chegar a 2×Limit; use apenas quando os clientes precisam prever
+	// o instante do reset.
+	Aligned bool
+	// Tempo de bloqueio ao exceder o limite (0 apenas rejeita até o fim da janela)
+	BlockTime time.Duration
+	// Limites por horário do dia e dia da semana, que substituem Limit durante os
+	// períodos do agendamento (opcional)
+	Schedule *Schedule
+}
+
+// window retorna a duração efetiva da janela
+func (r Rule) window() time.Duration {
+	if r.Window <= 0 {
+		return time.Second
+	}
+	return r.Window
+}
+
+// ruleFile é o formato JSON do arquivo de regras
+type ruleFile struct {
+	Rules []ruleJSON `json:"rules"`
+}
+
+// ruleJSON é a representação JSON de uma regra, com durações no formato de time.ParseDuration
+type ruleJSON struct {
+	Name      string        `json:"name"`
+	Limit     int           `json:"limit"`
+	Window    string        `json:"window"`
+	BlockTime string        `json:"block_time"`
+	Aligned   bool          `json:"aligned"`
+	Schedule  *scheduleJSON `json:"schedule"`
+}
+
+// scheduleJSON é a representação JSON de um agendamento, com o fuso horário no formato IANA
+type scheduleJSON struct {
+	Timezone string       `json:"timezone"`
+	Periods  []periodJSON `json:"periods"`
+}
+
+// periodJSON é a representação JSON de um período, com dias no formato mon-fri,sun e
+// horários no formato HH:MM
+type periodJSON struct {
+	Name  string `json:"name"`
+	Days  string `json:"days"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Limit int    `json:"limit"`
+}
+
+// ParseRules interpreta um arquivo de regras no formato:
+//
+//	{"rules": [{"name": "checkout", "limit": 100, "window": "1s", "block_time": "1m"}]}
+//
+// Com "aligned": true, as janelas da regra são alinhadas ao relógio.
+//
+// Uma regra pode ter um agendamento, com limites próprios por horário:
+//
+//	{"name": "partners", "limit": 100, "window": "1m", "schedule": {
+//		"timezone": "America/Sao_Paulo",
+//		"periods": [{"name": "night", "days": "mon-fri", "start": "22:00", "end": "06:00", "limit": 1000}]
+//	}}
+func ParseRules(data []byte) ([]Rule, error) {
+	var file ruleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("arquivo de regras inválido: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(file.Rules))
+	seen := make(map[string]bool)
+	for i, r := range file.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("regra %d: nome obrigatório", i)
+		}
+		if seen[r.Name] || r.Name == IPRuleName || r.Name == TokenRuleName {
+			return nil, fmt.Errorf("regra %s: nome duplicado ou reservado", r.Name)
+		}
+		seen[r.Name] = true
+
+		if r.Limit <= 0 {
+			return nil, fmt.Errorf("regra %s: limite deve ser maior que zero", r.Name)
+		}
+
+		window, err := parseRuleDuration(r.Window)
+		if err != nil {
+			return nil, fmt.Errorf("regra %s: janela inválida: %w", r.Name, err)
+		}
+		blockTime, err := parseRuleDuration(r.BlockTime)
+		if err != nil {
+			return nil, fmt.Errorf("regra %s: tempo de bloqueio inválido: %w", r.Name, err)
+		}
+
+		schedule, err := parseScheduleJSON(r.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("regra %s: agendamento inválido: %w", r.Name, err)
+		}
+
+		rules = append(rules, Rule{
+			Name:      r.Name,
+			Limit:     r.Limit,
+			Window:    window,
+			BlockTime: blockTime,
+			Aligned:   r.Aligned,
+			Schedule:  schedule,
+		})
+	}
+
+	return rules, nil
+}
+
+// parseScheduleJSON converte o agendamento opcional de uma regra
+func parseScheduleJSON(s *scheduleJSON) (*Schedule, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	schedule := &Schedule{Location: time.UTC}
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Location = loc
+	}
+
+	for i, p := range s.Periods {
+		if p.Limit <= 0 {
+			return nil, fmt.Errorf("período %d: limite deve ser maior que zero", i)
+		}
+		days, err := ParseScheduleDays(p.Days)
+		if err != nil {
+			return nil, fmt.Errorf("período %d: %w", i, err)
+		}
+		start, err := ParseScheduleTime(p.Start)
+		if err != nil {
+			return nil, fmt.Errorf("período %d: %w", i, err)
+		}
+		end, err := ParseScheduleTime(p.End)
+		if err != nil {
+			return nil, fmt.Errorf("período %d: %w", i, err)
+		}
+
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%s", p.Start, p.End)
+		}
+		schedule.Periods = append(schedule.Periods, SchedulePeriod{
+			Name:  name,
+			Days:  days,
+			Start: start,
+			End:   end,
+			Limit: p.Limit,
+		})
+	}
+
+	return schedule, nil
+}
+
+// LoadRulesFile lê e interpreta um arquivo de regras
+func LoadRulesFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler arquivo de regras: %w", err)
+	}
+	return ParseRules(data)
+}
+
+// parseRuleDuration interpreta uma duração opcional
+func parseRuleDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duração negativa: %s", value)
+	}
+	return d, nil
+}
+
+// descriptorEscaper escapa os separadores da chave de descritores (e o próprio caractere
+// de escape), para que valores enviados pelo cliente não formem a chave de outra combinação
+var descriptorEscaper = strings.NewReplacer("%", "%25", ":", "%3A", "=", "%3D")
+
+// descriptorUnescaper desfaz o escape de descriptorEscaper
+var descriptorUnescaper = strings.NewReplacer("%25", "%", "%3A", ":", "%3D", "=")
+
+// descriptorKey forma a chave de armazenamento de uma regra a partir dos descritores,
+// ordenados para que a mesma combinação sempre gere a mesma chave
+func descriptorKey(rule string, descriptors map[string]string) string {
+	names := make([]string, 0, len(descriptors))
+	for name := range descriptors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("rule:")
+	descriptorEscaper.WriteString(&b, rule)
+	for _, name := range names {
+		b.WriteString(":")
+		descriptorEscaper.WriteString(&b, name)
+		b.WriteString("=")
+		descriptorEscaper.WriteString(&b, descriptors[name])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchedulePeriod é um intervalo de horário, em alguns dias da semana, com um limite próprio
+type SchedulePeriod struct {
+	// Nome do período, informado em Decision.Schedule (por exemplo, "night")
+	Name string
+	// Dias da semana em que o período começa (vazio equivale a todos os dias)
+	Days []time.Weekday
+	// Início e fim do período, contados a partir da meia-noite. Um fim menor que o início
+	// atravessa a meia-noite (22:00-06:00 começa em um dia e termina no seguinte), e um
+	// fim igual ao início cobre o dia inteiro.
+	Start time.Duration
+	End   time.Duration
+	// Limite de requisições por janela durante o período
+	Limit int
+}
+
+// Schedule define limites diferentes por horário do dia e dia da semana. O primeiro
+// período que contém o instante da decisão define o limite; fora de todos os períodos,
+// vale o limite estático da regra.
+type Schedule struct {
+	// Períodos do agendamento, verificados em ordem
+	Periods []SchedulePeriod
+	// Fuso horário dos horários dos períodos (nil usa UTC)
+	Location *time.Location
+}
+
+// active retorna o período em vigor no instante informado
+func (s *Schedule) active(now time.Time) (SchedulePeriod, bool) {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t := now.In(loc)
+
+	// O horário vem do relógio de parede, e não da diferença para a meia-noite, que nos
+	// dias de horário de verão tem uma hora a mais ou a menos
+	elapsed := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	yesterday := (t.Weekday() + 6) % 7
+
+	for _, period := range s.Periods {
+		switch {
+		case period.Start == period.End:
+			if period.runsOn(t.Weekday()) {
+				return period, true
+			}
+		case period.Start < period.End:
+			if period.runsOn(t.Weekday()) && elapsed >= period.Start && elapsed < period.End {
+				return period, true
+			}
+		default:
+			// Período que atravessa a meia-noite: a parte noturna pertence ao dia de
+			// hoje e a parte da madrugada ao período que começou ontem
+			if (period.runsOn(t.Weekday()) && elapsed >= period.Start) ||
+				(period.runsOn(yesterday) && elapsed < period.End) {
+				return period, true
+			}
+		}
+	}
+
+	return SchedulePeriod{}, false
+}
+
+// runsOn indica se o período começa no dia da semana informado
+func (p SchedulePeriod) runsOn(day time.Weekday) bool {
+	if len(p.Days) == 0 {
+		return true
+	}
+	for _, d := range p.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdays mapeia as abreviações aceitas nos agendamentos para os dias da semana
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseScheduleDays converte uma lista de dias no formato mon-fri,sun em dias da semana.
+// Intervalos podem atravessar o domingo (fri-mon).
+func ParseScheduleDays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(entry, "-")
+		start, ok := weekdays[first]
+		if !ok {
+			return nil, fmt.Errorf("dia da semana inválido: %s", first)
+		}
+		end := start
+		if isRange {
+			if end, ok = weekdays[last]; !ok {
+				return nil, fmt.Errorf("dia da semana inválido: %s", last)
+			}
+		}
+		for day := start; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == end {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// ParseScheduleTime converte um horário no formato HH:MM no tempo desde a meia-noite.
+// 24:00 é aceito como fim do dia.
+func ParseScheduleTime(value string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(value), ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, fmt.Errorf("horário inválido: %s", value)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// ParseSchedule converte uma lista no formato mon-fri 09:00-18:00=50;22:00-06:00=1000 em
+// um agendamento. Os períodos são separados por ponto e vírgula, já que a vírgula separa
+// os dias (mon,wed 09:00-18:00=50). Os dias são opcionais, e o nome de cada período é o
+// seu próprio texto (por exemplo, "mon-fri 09:00-18:00").
+func ParseSchedule(value string, loc *time.Location) (*Schedule, error) {
+	schedule := &Schedule{Location: loc}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		spec, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("período inválido: %s", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("limite inválido para o período %s: %s", spec, limit)
+		}
+
+		spec = strings.TrimSpace(spec)
+		period := SchedulePeriod{Name: spec, Limit: n}
+		hours := spec
+		if days, rest, ok := strings.Cut(spec, " "); ok {
+			if period.Days, err = ParseScheduleDays(days); err != nil {
+				return nil, err
+			}
+			hours = strings.TrimSpace(rest)
+		}
+
+		start, end, ok := strings.Cut(hours, "-")
+		if !ok {
+			return nil, fmt.Errorf("período inválido: %s", entry)
+		}
+		if period.Start, err = ParseScheduleTime(start); err != nil {
+			return nil, err
+		}
+		if period.End, err = ParseScheduleTime(end); err != nil {
+			return nil, err
+		}
+		schedule.Periods = append(schedule.Periods, period)
+	}
+
+	if len(schedule.Periods) == 0 {
+		return nil, nil
+	}
+	return schedule, nil
+}
+
+// scheduleFor retorna o agendamento da regra: o definido por WithSchedule ou, para regras
+// nomeadas, o do próprio Rule
+func (rl *RateLimiter) scheduleFor(rule Rule) *Schedule {
+	if schedule, ok := rl.schedules[rule.Name]; ok {
+		return schedule
+	}
+	return rule.Schedule
+}
+
+// applySchedule substitui o limite estático da regra pelo limite do período em vigor e
+// retorna o nome do período ("" quando nenhum período se aplica)
+func (rl *RateLimiter) applySchedule(rule Rule) (Rule, string) {
+	schedule := rl.scheduleFor(rule)
+	if schedule == nil {
+		return rule, ""
+	}
+	period, ok := schedule.active(rl.clock.Now())
+	if !ok {
+		return rule, ""
+	}
+	rule.Limit = period.Limit
+	return rule, period.Name
+}
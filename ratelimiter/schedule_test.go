@@ -0,0 +1,180 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Active(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatalf("erro ao carregar fuso horário: %v", err)
+	}
+	schedule, err := ParseSchedule("mon-fri 09:00-18:00=50;fri 22:00-06:00=1000", saoPaulo)
+	if err != nil {
+		t.Fatalf("erro ao interpretar agendamento: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		// 2025-01-01 é uma quarta-feira
+		{"horário comercial", time.Date(2025, 1, 1, 10, 0, 0, 0, saoPaulo), "mon-fri 09:00-18:00"},
+		{"fim exclusivo", time.Date(2025, 1, 1, 18, 0, 0, 0, saoPaulo), ""},
+		{"horário em UTC convertido para o fuso", time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC), "mon-fri 09:00-18:00"},
+		{"noite de sexta", time.Date(2025, 1, 3, 23, 0, 0, 0, saoPaulo), "fri 22:00-06:00"},
+		{"madrugada de sábado", time.Date(2025, 1, 4, 5, 59, 0, 0, saoPaulo), "fri 22:00-06:00"},
+		{"madrugada de sexta", time.Date(2025, 1, 3, 5, 0, 0, 0, saoPaulo), ""},
+		{"sábado à tarde", time.Date(2025, 1, 4, 10, 0, 0, 0, saoPaulo), ""},
+	}
+	for _, tt := range tests {
+		period, _ := schedule.active(tt.at)
+		if period.Name != tt.want {
+			t.Errorf("%s: período deveria ser %q, mas recebeu %q", tt.name, tt.want, period.Name)
+		}
+	}
+}
+
+func TestSchedule_ActiveDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("erro ao carregar fuso horário: %v", err)
+	}
+	schedule, err := ParseSchedule("sun 10:00-11:00=5", newYork)
+	if err != nil {
+		t.Fatalf("erro ao interpretar agendamento: %v", err)
+	}
+
+	// Em 2025-03-09 os relógios pulam das 02:00 para as 03:00, e o dia tem 23 horas
+	at := time.Date(2025, 3, 9, 10, 30, 0, 0, newYork)
+	if period, ok := schedule.active(at); !ok || period.Name != "sun 10:00-11:00" {
+		t.Errorf("período deveria estar em vigor às 10:30 do dia da mudança de horário, mas recebeu %q", period.Name)
+	}
+	if _, ok := schedule.active(at.Add(time.Hour)); ok {
+		t.Error("período não deveria estar em vigor às 11:30 do dia da mudança de horário")
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("sat-mon 00:00-24:00=10; 08:00-12:00=20; mon,wed 18:00-20:00=30", nil)
+	if err != nil {
+		t.Fatalf("erro ao interpretar agendamento: %v", err)
+	}
+	if len(schedule.Periods) != 3 {
+		t.Fatalf("deveria interpretar 3 períodos, mas recebeu %d", len(schedule.Periods))
+	}
+
+	weekend := schedule.Periods[0]
+	if len(weekend.Days) != 3 || weekend.Days[0] != time.Saturday || weekend.Days[2] != time.Monday {
+		t.Errorf("dias deveriam ser sábado a segunda, mas recebeu %v", weekend.Days)
+	}
+	if weekend.End != 24*time.Hour || weekend.Limit != 10 {
+		t.Errorf("período inesperado: %+v", weekend)
+	}
+	if daily := schedule.Periods[1]; len(daily.Days) != 0 || daily.Start != 8*time.Hour || daily.End != 12*time.Hour {
+		t.Errorf("período sem dias deveria valer todos os dias, mas recebeu %+v", daily)
+	}
+	if evening := schedule.Periods[2]; len(evening.Days) != 2 || evening.Days[0] != time.Monday || evening.Days[1] != time.Wednesday {
+		t.Errorf("dias separados por vírgula deveriam ser segunda e quarta, mas recebeu %v", evening.Days)
+	}
+
+	if schedule, err := ParseSchedule("", nil); err != nil || schedule != nil {
+		t.Errorf("agendamento vazio deveria ser nil (agendamento: %+v, erro: %v)", schedule, err)
+	}
+
+	invalid := []string{
+		"09:00-18:00",
+		"09:00-18:00=0",
+		"xyz 09:00-18:00=10",
+		"mon 9h-18h=10",
+		"mon 09:00-25:00=10",
+	}
+	for _, value := range invalid {
+		if _, err := ParseSchedule(value, nil); err == nil {
+			t.Errorf("deveria rejeitar o agendamento %s", value)
+		}
+	}
+}
+
+func TestRateLimiter_Schedule(t *testing.T) {
+	// 2025-01-01 às 12h é uma quarta-feira, fora do período noturno
+	schedule := &Schedule{Periods: []SchedulePeriod{
+		{Name: "night", Start: 22 * time.Hour, End: 6 * time.Hour, Limit: 3},
+	}}
+	limiter, fakeClock := newTestLimiter(t,
+		WithTokenLimit(1, 0),
+		WithSchedule(TokenRuleName, schedule),
+		WithRules(Rule{Name: "partners", Limit: 1, Window: time.Minute, Schedule: schedule}),
+	)
+
+	ctx := context.Background()
+	req := &LimiterRequest{IP: "192.168.1.1", Token: "abc"}
+
+	// Durante o dia vale o limite estático
+	decision, err := limiter.Decide(ctx, req)
+	if err != nil {
+		t.Fatalf("primeira requisição deveria ser permitida: %v", err)
+	}
+	if decision.Limit != 1 || decision.Schedule != "" {
+		t.Errorf("deveria usar o limite estático, mas recebeu limite %d e período %q", decision.Limit, decision.Schedule)
+	}
+	if _, err := limiter.Decide(ctx, req); err == nil {
+		t.Error("segunda requisição deveria exceder o limite estático")
+	}
+
+	// À noite, o limite do período substitui o estático
+	fakeClock.Advance(11 * time.Hour)
+	for i := 0; i < 3; i++ {
+		decision, err = limiter.Decide(ctx, req)
+		if err != nil {
+			t.Fatalf("requisição %d deveria ser permitida no período noturno: %v", i+1, err)
+		}
+	}
+	if decision.Limit != 3 || decision.Schedule != "night" {
+		t.Errorf("deveria usar o período noturno, mas recebeu limite %d e período %q", decision.Limit, decision.Schedule)
+	}
+
+	// Regras nomeadas usam o agendamento do próprio Rule
+	decision, err = limiter.Check(ctx, &CheckRequest{Rule: "partners", Descriptors: map[string]string{"partner": "acme"}})
+	if err != nil {
+		t.Fatalf("erro ao verificar regra: %v", err)
+	}
+	if decision.Limit != 3 || decision.Schedule != "night" {
+		t.Errorf("regra nomeada deveria usar o período noturno, mas recebeu limite %d e período %q", decision.Limit, decision.Schedule)
+	}
+}
+
+func TestParseRules_Schedule(t *testing.T) {
+	rules, err := ParseRules([]byte(`{"rules": [{"name": "partners", "limit": 100, "schedule": {
+		"timezone": "America/Sao_Paulo",
+		"periods": [{"name": "night", "days": "mon-fri", "start": "22:00", "end": "06:00", "limit": 1000}]
+	}}]}`))
+	if err != nil {
+		t.Fatalf("erro ao interpretar regras: %v", err)
+	}
+
+	schedule := rules[0].Schedule
+	if schedule == nil || len(schedule.Periods) != 1 {
+		t.Fatalf("regra deveria ter um período, mas recebeu %+v", schedule)
+	}
+	if schedule.Location.String() != "America/Sao_Paulo" {
+		t.Errorf("fuso horário deveria ser America/Sao_Paulo, mas recebeu %s", schedule.Location)
+	}
+	if period := schedule.Periods[0]; period.Name != "night" || len(period.Days) != 5 || period.Limit != 1000 {
+		t.Errorf("período inesperado: %+v", period)
+	}
+
+	invalid := []string{
+		`{"rules": [{"name": "a", "limit": 1, "schedule": {"timezone": "Mars/Base"}}]}`,
+		`{"rules": [{"name": "a", "limit": 1, "schedule": {"periods": [{"start": "22:00", "end": "06:00"}]}}]}`,
+		`{"rules": [{"name": "a", "limit": 1, "schedule": {"periods": [{"start": "22h", "end": "06:00", "limit": 1}]}}]}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseRules([]byte(data)); err == nil {
+			t.Errorf("deveria rejeitar o arquivo de regras %s", data)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DefaultCompactionInterval é o intervalo padrão da remoção de entradas expiradas do BoltStore
+	DefaultCompactionInterval = time.Minute
+
+	// compactionBatchSize limita quantas chaves são visitadas por transação na compactação
+	compactionBatchSize = 1000
+)
+
+var (
+	countsBucket = []byte("counts")
+	blocksBucket = []byte("blocks")
+)
+
+// BoltStoreOptions configura o BoltStore
+type BoltStoreOptions struct {
+	// Caminho do arquivo do banco de dados
+	Path string
+	// Intervalo entre remoções de entradas expiradas (0 usa DefaultCompactionInterval)
+	CompactionInterval time.Duration
+	// Relógio usado para expiração e bloqueios (nil usa o relógio do sistema)
+	Clock clock.Clock
+}
+
+// BoltStore implementa RateLimiterStore usando um banco de dados embutido em disco (bbolt).
+// Cada operação é uma transação com fsync no commit, então bloqueios sobrevivem a
+// reinicializações e quedas do processo sem depender de um serviço externo.
+type BoltStore struct {
+	db    *bolt.DB
+	clock clock.Clock
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBoltStore abre (ou cria) o banco de dados e inicia a rotina de compactação
+func NewBoltStore(opts BoltStoreOptions) (*BoltStore, error) {
+	db, err := bolt.Open(opts.Path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir banco de dados %s: %w", opts.Path, err)
+	}
+
+	// Garante que os buckets existem
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{countsBucket, blocksBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("falha ao inicializar banco de dados: %w", err)
+	}
+
+	interval := opts.CompactionInterval
+	if interval <= 0 {
+		interval = DefaultCompactionInterval
+	}
+
+	s := &BoltStore{
+		db:    db,
+		clock: clock.OrDefault(opts.Clock),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go s.compactionRoutine(interval)
+
+	return s, nil
+}
+
+// GetRequestCount obtém o número atual de requisições para uma chave
+func (s *BoltStore) GetRequestCount(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c, expiresAt, ok := decodeCount(tx.Bucket(countsBucket).Get([]byte(key)))
+		if ok && s.clock.Now().Before(expiresAt) {
+			count = c
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// IncrementRequestCount incrementa o contador de requisições para uma chave
+func (s *BoltStore) IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error) {
+	return s.IncrementRequestCountBy(ctx, key, 1, expiration)
+}
+
+// IncrementRequestCountBy soma amount ao contador de requisições de uma chave
+func (s *BoltStore) IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error) {
+	count, _, err := s.IncrementRequestCountTTL(ctx, key, amount, expiration)
+	return count, err
+}
+
+// IncrementRequestCountTTL soma amount ao contador e retorna também o tempo até o fim da janela
+func (s *BoltStore) IncrementRequestCountTTL(ctx context.Context, key string, amount int, expiration time.Duration) (int, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		count int
+		ttl   time.Duration
+	)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(countsBucket)
+		now := s.clock.Now()
+
+		c, expiresAt, ok := decodeCount(bucket.Get([]byte(key)))
+		// Se a janela expirou (ou não existe), inicia uma nova; uma devolução não tem o
+		// que devolver e não cria a janela
+		if !ok || !now.Before(expiresAt) {
+			if amount < 0 {
+				return nil
+			}
+			c = 0
+			expiresAt = now.Add(expiration)
+		}
+		count = max(c+amount, 0)
+		ttl = expiresAt.Sub(now)
+
+		return bucket.Put([]byte(key), encodeCount(count, expiresAt))
+	})
+
+	return count, ttl, err
+}
+
+// GetRequestCountTTL obtém a contagem atual e o tempo até o fim da janela de uma chave
+func (s *BoltStore) GetRequestCountTTL(ctx context.Context, key string) (int, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		count int
+		ttl   time.Duration
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		now := s.clock.Now()
+		c, expiresAt, ok := decodeCount(tx.Bucket(countsBucket).Get([]byte(key)))
+		if ok && now.Before(expiresAt) {
+			count, ttl = c, expiresAt.Sub(now)
+		}
+		return nil
+	})
+
+	return count, ttl, err
+}
+
+// IsBlocked verifica se uma chave está bloqueada
+func (s *BoltStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var blocked bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		blockedUntil, ok := decodeTime(tx.Bucket(blocksBucket).Get([]byte(key)))
+		blocked = ok && s.clock.Now().Before(blockedUntil)
+		return nil
+	})
+
+	return blocked, err
+}
+
+// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar
+func (s *BoltStore) BlockTTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var ttl time.Duration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		blockedUntil, ok := decodeTime(tx.Bucket(blocksBucket).Get([]byte(key)))
+		if ok {
+			ttl = max(blockedUntil.Sub(s.clock.Now()), 0)
+		}
+		return nil
+	})
+
+	return ttl, err
+}
+
+// Block bloqueia uma chave pelo tempo de bloqueio especificado
+func (s *BoltStore) Block(ctx context.Context, key string, blockTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(blocksBucket).Put([]byte(key), encodeTime(s.clock.Now().Add(blockTime))); err != nil {
+			return err
+		}
+
+		// Resetamos o contador quando bloqueamos
+		return tx.Bucket(countsBucket).Delete([]byte(key))
+	})
+}
+
+// Close encerra a rotina de compactação e fecha o banco de dados
+func (s *BoltStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		err = s.db.Close()
+	})
+	return err
+}
+
+// compactionRoutine remove periodicamente as entradas expiradas
+func (s *BoltStore) compactionRoutine(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Erros são ignorados: a próxima execução tentará novamente
+			_ = s.compact()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// compact remove contadores e bloqueios expirados em lotes, para não manter
+// transações de escrita longas que bloqueariam as requisições
+func (s *BoltStore) compact() error {
+	for _, bucket := range []struct {
+		name    []byte
+		expired func(value []byte, now time.Time) bool
+	}{
+		{countsBucket, func(v []byte, now time.Time) bool {
+			_, expiresAt, ok := decodeCount(v)
+			return !ok || !now.Before(expiresAt)
+		}},
+		{blocksBucket, func(v []byte, now time.Time) bool {
+			blockedUntil, ok := decodeTime(v)
+			return !ok || !now.Before(blockedUntil)
+		}},
+	} {
+		// Cada lote continua a partir da última chave visitada no lote anterior, então as
+		// chaves válidas são percorridas uma única vez por compactação
+		var last []byte
+		for done := false; !done; {
+			err := s.db.Update(func(tx *bolt.Tx) error {
+				now := s.clock.Now()
+				b := tx.Bucket(bucket.name)
+
+				// Coleta as chaves antes de remover, pois remover durante a iteração do cursor pode pular itens
+				var keys [][]byte
+				c := b.Cursor()
+				k, v := c.First()
+				if last != nil {
+					k, v = c.Seek(last)
+					if k != nil && string(k) == string(last) {
+						k, v = c.Next()
+					}
+				}
+				for visited := 0; k != nil && visited < compactionBatchSize; k, v = c.Next() {
+					if bucket.expired(v, now) {
+						keys = append(keys, append([]byte(nil), k...))
+					}
+					last = append(last[:0], k...)
+					visited++
+				}
+				done = k == nil
+
+				for _, k := range keys {
+					if err := b.Delete(k); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// encodeCount serializa um contador e o instante de expiração da janela
+func encodeCount(count int, expiresAt time.Time) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], uint64(count))
+	binary.BigEndian.PutUint64(buf[8:], uint64(expiresAt.UnixNano()))
+	return buf
+}
+
+// decodeCount desserializa um valor gravado por encodeCount
+func decodeCount(buf []byte) (int, time.Time, bool) {
+	if len(buf) != 16 {
+		return 0, time.Time{}, false
+	}
+	count := int(binary.BigEndian.Uint64(buf[:8]))
+	expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(buf[8:])))
+	return count, expiresAt, true
+}
+
+// encodeTime serializa um instante em nanossegundos
+func encodeTime(t time.Time) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(t.UnixNano()))
+	return buf
+}
+
+// decodeTime desserializa um valor gravado por encodeTime
+func decodeTime(buf []byte) (time.Time, bool) {
+	if len(buf) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(buf))), true
+}
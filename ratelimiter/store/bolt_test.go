@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltStore_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimiter.db")
+	ctx := context.Background()
+
+	// Bloqueia uma chave e fecha o banco
+	s, err := NewBoltStore(BoltStoreOptions{Path: path})
+	if err != nil {
+		t.Fatalf("falha ao criar Bolt store: %v", err)
+	}
+	if err := s.Block(ctx, "ip:192.168.1.1", time.Minute); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+	if _, err := s.IncrementRequestCount(ctx, "token:abc", time.Minute); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("erro ao fechar Bolt store: %v", err)
+	}
+
+	// Reabre o banco e verifica se o estado foi preservado
+	s, err = NewBoltStore(BoltStoreOptions{Path: path})
+	if err != nil {
+		t.Fatalf("falha ao reabrir Bolt store: %v", err)
+	}
+	defer s.Close()
+
+	blocked, err := s.IsBlocked(ctx, "ip:192.168.1.1")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio: %v", err)
+	}
+	if !blocked {
+		t.Error("bloqueio deveria sobreviver à reinicialização")
+	}
+
+	count, err := s.GetRequestCount(ctx, "token:abc")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("contagem deveria ser 1 após reinicialização, mas recebeu %d", count)
+	}
+}
+
+func TestBoltStore_Compaction(t *testing.T) {
+	s, err := NewBoltStore(BoltStoreOptions{
+		Path:               filepath.Join(t.TempDir(), "ratelimiter.db"),
+		CompactionInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("falha ao criar Bolt store: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Cria entradas que expiram rapidamente e uma que permanece válida
+	if _, err := s.IncrementRequestCount(ctx, "ip:1", 10*time.Millisecond); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if err := s.Block(ctx, "ip:2", 10*time.Millisecond); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+	if err := s.Block(ctx, "ip:3", time.Minute); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if err := s.compact(); err != nil {
+		t.Fatalf("erro na compactação: %v", err)
+	}
+
+	// Apenas o bloqueio ainda válido deve permanecer no banco
+	var counts, blocks int
+	err = s.db.View(func(tx *bolt.Tx) error {
+		counts = tx.Bucket(countsBucket).Stats().KeyN
+		blocks = tx.Bucket(blocksBucket).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("erro ao ler banco: %v", err)
+	}
+	if counts != 0 || blocks != 1 {
+		t.Errorf("esperava 0 contadores e 1 bloqueio após compactação, mas recebeu %d e %d", counts, blocks)
+	}
+}
+
+func TestBoltStore_CompactionAcrossBatches(t *testing.T) {
+	s, err := NewBoltStore(BoltStoreOptions{
+		Path:               filepath.Join(t.TempDir(), "ratelimiter.db"),
+		CompactionInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("falha ao criar Bolt store: %v", err)
+	}
+	defer s.Close()
+
+	// Mais chaves que um lote, alternando contadores válidos e expirados
+	now := time.Now()
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(countsBucket)
+		for i := 0; i < 2*compactionBatchSize+10; i++ {
+			expiresAt := now.Add(time.Minute)
+			if i%2 == 1 {
+				expiresAt = now.Add(-time.Minute)
+			}
+			if err := b.Put([]byte(fmt.Sprintf("ip:%05d", i)), encodeCount(1, expiresAt)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("erro ao preparar banco: %v", err)
+	}
+
+	if err := s.compact(); err != nil {
+		t.Fatalf("erro na compactação: %v", err)
+	}
+
+	var counts int
+	err = s.db.View(func(tx *bolt.Tx) error {
+		counts = tx.Bucket(countsBucket).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("erro ao ler banco: %v", err)
+	}
+	if want := compactionBatchSize + 5; counts != want {
+		t.Errorf("esperava %d contadores válidos após compactação, mas recebeu %d", want, counts)
+	}
+}
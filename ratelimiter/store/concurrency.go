@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// ConcurrencyStore é implementado pelos armazenamentos que suportam limite de requisições
+// simultâneas. É uma interface opcional: verifique com uma type assertion.
+type ConcurrencyStore interface {
+	// Acquire tenta reservar uma das limit vagas da chave. Retorna o identificador da
+	// reserva e true, ou false se todas as vagas estão ocupadas. A reserva expira após ttl,
+	// liberando a vaga mesmo que a instância que a adquiriu pare sem chamar Release.
+	Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (string, bool, error)
+
+	// Renew estende a expiração de uma reserva para ttl a partir de agora. Retorna false
+	// se a reserva já expirou ou foi liberada.
+	Renew(ctx context.Context, key string, lease string, ttl time.Duration) (bool, error)
+
+	// Release libera a vaga de uma reserva
+	Release(ctx context.Context, key string, lease string) error
+}
+
+// newLeaseID gera um identificador aleatório para uma reserva
+func newLeaseID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package store_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pperesbr/ratelimiter/clock"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+	"github.com/pperesbr/ratelimiter/ratelimiter/store/storetest"
+	_ "modernc.org/sqlite"
+)
+
+// fakeClockOptions retorna um relógio falso e as opções do suite que o avançam
+func fakeClockOptions() (*clock.Fake, storetest.Options) {
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	return fakeClock, storetest.Options{
+		Advance: func(t *testing.T, s store.RateLimiterStore, d time.Duration) {
+			fakeClock.Advance(d)
+		},
+	}
+}
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	fakeClock, opts := fakeClockOptions()
+	storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+		return store.NewMemoryStoreWithOptions(store.MemoryStoreOptions{Clock: fakeClock})
+	}, opts)
+}
+
+func TestShardedMemoryStore_Conformance(t *testing.T) {
+	fakeClock, opts := fakeClockOptions()
+	storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+		return store.NewShardedMemoryStore(4, store.MemoryStoreOptions{Clock: fakeClock})
+	}, opts)
+}
+
+func TestBoltStore_Conformance(t *testing.T) {
+	fakeClock, opts := fakeClockOptions()
+	storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+		s, err := store.NewBoltStore(store.BoltStoreOptions{
+			Path:  filepath.Join(t.TempDir(), "ratelimiter.db"),
+			Clock: fakeClock,
+		})
+		if err != nil {
+			t.Fatalf("falha ao criar Bolt store: %v", err)
+		}
+		return s
+	}, opts)
+}
+
+func TestSQLStore_Conformance(t *testing.T) {
+	fakeClock, opts := fakeClockOptions()
+	storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ratelimiter.db"))
+		if err != nil {
+			t.Fatalf("falha ao abrir SQLite: %v", err)
+		}
+		s, err := store.NewSQLStore(db, store.SQLStoreOptions{Dialect: store.DialectSQLite, Clock: fakeClock})
+		if err != nil {
+			db.Close()
+			t.Fatalf("falha ao criar SQL store: %v", err)
+		}
+		return s
+	}, opts)
+}
+
+func TestMemcachedStore_Conformance(t *testing.T) {
+	var fake *store.FakeMemcached
+
+	storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+		fake = store.NewFakeMemcached(t)
+		s, err := store.NewMemcachedStore(store.MemcachedStoreOptions{Addr: fake.Addr()})
+		if err != nil {
+			t.Fatalf("falha ao criar Memcached store: %v", err)
+		}
+		return s
+	}, storetest.Options{
+		// O Memcached expira chaves com resolução de segundos
+		Granularity: time.Second,
+		Advance: func(t *testing.T, s store.RateLimiterStore, d time.Duration) {
+			fake.Advance(d)
+		},
+	})
+}
+
+func TestRedisStore_Conformance(t *testing.T) {
+	var server *miniredis.Miniredis
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+		// Redis em processo, dispensando TEST_INTEGRATION
+		server = miniredis.RunT(t)
+		server.SetTime(fakeClock.Now())
+		s, err := store.NewRedisStore(store.RedisStoreOptions{Addr: server.Addr()})
+		if err != nil {
+			t.Fatalf("falha ao criar Redis store: %v", err)
+		}
+		return s
+	}, storetest.Options{
+		Advance: func(t *testing.T, s store.RateLimiterStore, d time.Duration) {
+			// As vagas de concorrência e os baldes de fichas usam o TIME do Redis
+			server.FastForward(d)
+			fakeClock.Advance(d)
+			server.SetTime(fakeClock.Now())
+		},
+	})
+}
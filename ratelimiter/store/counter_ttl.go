@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// CounterTTLStore é implementado pelos armazenamentos que informam quanto falta para a
+// janela de um contador terminar, usado para calcular o tempo até o reset. É uma interface
+// opcional: verifique com uma type assertion.
+type CounterTTLStore interface {
+	// IncrementRequestCountTTL soma amount ao contador como IncrementRequestCountBy e
+	// retorna também o tempo até o fim da janela do contador
+	IncrementRequestCountTTL(ctx context.Context, key string, amount int, expiration time.Duration) (int, time.Duration, error)
+
+	// GetRequestCountTTL retorna a contagem atual e o tempo até o fim da janela do
+	// contador (0 e 0 se não há janela ativa)
+	GetRequestCountTTL(ctx context.Context, key string) (int, time.Duration, error)
+}
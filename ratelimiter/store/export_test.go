@@ -0,0 +1,26 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// FakeMemcached expõe o servidor Memcached falso para os testes do pacote store_test
+type FakeMemcached struct {
+	fake *fakeMemcached
+}
+
+// NewFakeMemcached inicia um servidor Memcached falso em processo
+func NewFakeMemcached(t *testing.T) *FakeMemcached {
+	return &FakeMemcached{fake: newFakeMemcached(t)}
+}
+
+// Addr retorna o endereço do servidor falso
+func (f *FakeMemcached) Addr() string {
+	return f.fake.addr()
+}
+
+// Advance avança o relógio do servidor falso
+func (f *FakeMemcached) Advance(d time.Duration) {
+	f.fake.advance(d)
+}
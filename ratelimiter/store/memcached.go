@@ -0,0 +1,465 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+)
+
+const (
+	// DefaultMemcachedTimeout é o tempo máximo padrão de cada operação no Memcached
+	DefaultMemcachedTimeout = time.Second
+
+	// DefaultMemcachedMaxIdleConns é o número padrão de conexões ociosas mantidas
+	DefaultMemcachedMaxIdleConns = 16
+
+	// memcachedMaxKeyLength é o tamanho máximo de chave aceito pelo Memcached
+	memcachedMaxKeyLength = 250
+
+	// memcachedMaxRelativeExpiration é o maior TTL relativo; acima disso o Memcached
+	// interpreta o valor como um timestamp Unix
+	memcachedMaxRelativeExpiration = 30 * 24 * time.Hour
+)
+
+var (
+	errMemcachedNotFound  = errors.New("memcached: chave não encontrada")
+	errMemcachedNotStored = errors.New("memcached: valor não armazenado")
+	errMemcachedClosed    = errors.New("memcached: armazenamento fechado")
+)
+
+// MemcachedStoreOptions configura o MemcachedStore
+type MemcachedStoreOptions struct {
+	// Endereço do servidor (host:porta)
+	Addr string
+	// Tempo máximo de cada operação (0 usa DefaultMemcachedTimeout)
+	Timeout time.Duration
+	// Número máximo de conexões ociosas mantidas (0 usa DefaultMemcachedMaxIdleConns)
+	MaxIdleConns int
+	// Relógio usado para calcular expirações absolutas (nil usa o relógio do sistema)
+	Clock clock.Clock
+}
+
+// MemcachedStore implementa RateLimiterStore usando o protocolo texto do Memcached.
+// Contadores usam add/incr com expiração e bloqueios usam add com expiração.
+type MemcachedStore struct {
+	addr    string
+	timeout time.Duration
+	idle    chan *memcachedConn
+	clock   clock.Clock
+
+	// closed impede que novas conexões sejam abertas ou devolvidas ao pool após Close
+	mu     sync.Mutex
+	closed bool
+}
+
+// memcachedConn é uma conexão com o servidor e seu leitor bufferizado
+type memcachedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewMemcachedStore cria uma nova instância de MemcachedStore e testa a conexão
+func NewMemcachedStore(opts MemcachedStoreOptions) (*MemcachedStore, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultMemcachedTimeout
+	}
+	maxIdle := opts.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = DefaultMemcachedMaxIdleConns
+	}
+
+	s := &MemcachedStore{
+		addr:    opts.Addr,
+		timeout: timeout,
+		idle:    make(chan *memcachedConn, maxIdle),
+		clock:   clock.OrDefault(opts.Clock),
+	}
+
+	// Testa a conexão
+	err := s.do(context.Background(), func(c *memcachedConn) error {
+		line, err := c.command("version\r\n")
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "VERSION") {
+			return fmt.Errorf("resposta inesperada: %s", line)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao conectar com Memcached: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetRequestCount obtém o número atual de requisições para uma chave
+func (s *MemcachedStore) GetRequestCount(ctx context.Context, key string) (int, error) {
+	var count int
+	err := s.do(ctx, func(c *memcachedConn) error {
+		value, err := c.get(memcachedKey("count:" + key))
+		if err == errMemcachedNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		count, err = strconv.Atoi(strings.TrimSpace(value))
+		return err
+	})
+
+	return count, err
+}
+
+// IncrementRequestCount incrementa o contador de requisições para uma chave
+func (s *MemcachedStore) IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error) {
+	return s.IncrementRequestCountBy(ctx, key, 1, expiration)
+}
+
+// IncrementRequestCountBy soma amount ao contador de requisições de uma chave
+func (s *MemcachedStore) IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error) {
+	countKey := memcachedKey("count:" + key)
+
+	var count int
+	err := s.do(ctx, func(c *memcachedConn) error {
+		// O incr do Memcached não aceita valores negativos; a devolução usa decr, que
+		// não passa de zero. Um contador que já expirou não precisa ser devolvido.
+		if amount < 0 {
+			value, err := c.decr(countKey, -amount)
+			if err == errMemcachedNotFound {
+				return nil
+			}
+			count = value
+			return err
+		}
+
+		// Duas tentativas: se outra instância criar o contador entre o incr e o add,
+		// o segundo incr encontra a chave
+		for attempt := 0; attempt < 2; attempt++ {
+			value, err := c.incr(countKey, amount)
+			if err == nil {
+				count = value
+				return nil
+			}
+			if err != errMemcachedNotFound {
+				return err
+			}
+
+			// Primeira requisição da janela: cria o contador com a expiração
+			err = c.store("add", countKey, strconv.Itoa(amount), memcachedExpiration(s.clock.Now(), expiration))
+			if err == nil {
+				count = amount
+				return nil
+			}
+			if err != errMemcachedNotStored {
+				return err
+			}
+		}
+		return fmt.Errorf("memcached: falha ao incrementar %s", countKey)
+	})
+
+	return count, err
+}
+
+// IsBlocked verifica se uma chave está bloqueada
+func (s *MemcachedStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	var blocked bool
+	err := s.do(ctx, func(c *memcachedConn) error {
+		_, err := c.get(memcachedKey("blocked:" + key))
+		if err == errMemcachedNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		blocked = true
+		return nil
+	})
+
+	return blocked, err
+}
+
+// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar.
+// O Memcached não informa o TTL de uma chave, então o fim do bloqueio é gravado no valor.
+func (s *MemcachedStore) BlockTTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := s.do(ctx, func(c *memcachedConn) error {
+		value, err := c.get(memcachedKey("blocked:" + key))
+		if err == errMemcachedNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		blockedUntil, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("memcached: valor de bloqueio inválido: %q", value)
+		}
+		ttl = max(time.UnixMilli(blockedUntil).Sub(s.clock.Now()), 0)
+		return nil
+	})
+
+	return ttl, err
+}
+
+// Block bloqueia uma chave pelo tempo de bloqueio especificado
+func (s *MemcachedStore) Block(ctx context.Context, key string, blockTime time.Duration) error {
+	return s.do(ctx, func(c *memcachedConn) error {
+		// add não sobrescreve um bloqueio existente: se a chave já está bloqueada,
+		// o bloqueio original é mantido. O valor guarda o fim do bloqueio para BlockTTL.
+		now := s.clock.Now()
+		blockedUntil := strconv.FormatInt(now.Add(blockTime).UnixMilli(), 10)
+		err := c.store("add", memcachedKey("blocked:"+key), blockedUntil, memcachedExpiration(now, blockTime))
+		if err != nil && err != errMemcachedNotStored {
+			return err
+		}
+
+		// Resetamos o contador quando bloqueamos
+		err = c.delete(memcachedKey("count:" + key))
+		if err == errMemcachedNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// Close fecha as conexões ociosas com o Memcached. As operações em andamento fecham
+// suas conexões ao terminar, e as seguintes retornam erro.
+func (s *MemcachedStore) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	for {
+		select {
+		case c := <-s.idle:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do executa fn com uma conexão do pool, respeitando o prazo do contexto.
+// Conexões que falharam são descartadas, pois podem ter respostas pendentes.
+func (s *MemcachedStore) do(ctx context.Context, fn func(c *memcachedConn) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c, err := s.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.SetDeadline(deadline)
+
+	err = fn(c)
+	if err != nil && err != errMemcachedNotFound && err != errMemcachedNotStored {
+		c.Close()
+		return err
+	}
+
+	s.release(c)
+	return err
+}
+
+// conn obtém uma conexão ociosa ou abre uma nova, a menos que o armazenamento esteja fechado
+func (s *MemcachedStore) conn(ctx context.Context) (*memcachedConn, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, errMemcachedClosed
+	}
+
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memcachedConn{Conn: nc, r: bufio.NewReader(nc)}, nil
+}
+
+// release devolve a conexão ao pool, ou a fecha se o pool está cheio ou o armazenamento
+// foi fechado. O lock garante que Close não deixe conexões devolvidas depois de esvaziar
+// o pool.
+func (s *MemcachedStore) release(c *memcachedConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		c.Close()
+		return
+	}
+	select {
+	case s.idle <- c:
+	default:
+		c.Close()
+	}
+}
+
+// command envia um comando e retorna a primeira linha da resposta
+func (c *memcachedConn) command(cmd string) (string, error) {
+	if _, err := c.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+	return c.readLine()
+}
+
+// readLine lê uma linha da resposta, sem o terminador \r\n
+func (c *memcachedConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	if line == "ERROR" || strings.HasPrefix(line, "CLIENT_ERROR") || strings.HasPrefix(line, "SERVER_ERROR") {
+		return "", fmt.Errorf("memcached: %s", line)
+	}
+	return line, nil
+}
+
+// get lê o valor de uma chave
+func (c *memcachedConn) get(key string) (string, error) {
+	line, err := c.command("get " + key + "\r\n")
+	if err != nil {
+		return "", err
+	}
+	if line == "END" {
+		return "", errMemcachedNotFound
+	}
+
+	// Formato: VALUE <chave> <flags> <bytes>
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[0] != "VALUE" {
+		return "", fmt.Errorf("memcached: resposta inesperada: %s", line)
+	}
+	size, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return "", fmt.Errorf("memcached: tamanho inválido: %s", line)
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return "", err
+	}
+	if end, err := c.readLine(); err != nil || end != "END" {
+		return "", fmt.Errorf("memcached: resposta incompleta para get %s", key)
+	}
+
+	return string(data[:size]), nil
+}
+
+// incr soma delta a um contador existente
+func (c *memcachedConn) incr(key string, delta int) (int, error) {
+	line, err := c.command("incr " + key + " " + strconv.Itoa(delta) + "\r\n")
+	if err != nil {
+		return 0, err
+	}
+	if line == "NOT_FOUND" {
+		return 0, errMemcachedNotFound
+	}
+	return strconv.Atoi(line)
+}
+
+// decr decrementa um contador numérico, retornando errMemcachedNotFound se ele não existe
+func (c *memcachedConn) decr(key string, delta int) (int, error) {
+	line, err := c.command("decr " + key + " " + strconv.Itoa(delta) + "\r\n")
+	if err != nil {
+		return 0, err
+	}
+	if line == "NOT_FOUND" {
+		return 0, errMemcachedNotFound
+	}
+	return strconv.Atoi(line)
+}
+
+// store executa um comando de armazenamento (add, set...) com expiração no formato do Memcached
+func (c *memcachedConn) store(cmd, key, value string, exptime int64) error {
+	line, err := c.command(fmt.Sprintf("%s %s 0 %d %d\r\n%s\r\n", cmd, key, exptime, len(value), value))
+	if err != nil {
+		return err
+	}
+
+	switch line {
+	case "STORED":
+		return nil
+	case "NOT_STORED":
+		return errMemcachedNotStored
+	default:
+		return fmt.Errorf("memcached: resposta inesperada: %s", line)
+	}
+}
+
+// delete remove uma chave
+func (c *memcachedConn) delete(key string) error {
+	line, err := c.command("delete " + key + "\r\n")
+	if err != nil {
+		return err
+	}
+
+	switch line {
+	case "DELETED":
+		return nil
+	case "NOT_FOUND":
+		return errMemcachedNotFound
+	default:
+		return fmt.Errorf("memcached: resposta inesperada: %s", line)
+	}
+}
+
+// memcachedExpiration converte uma duração para o formato de expiração do Memcached:
+// segundos (arredondados para cima) até 30 dias, ou timestamp Unix acima disso
+func memcachedExpiration(now time.Time, d time.Duration) int64 {
+	if d > memcachedMaxRelativeExpiration {
+		return now.Add(d).Unix()
+	}
+
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// memcachedKey garante que a chave é aceita pelo Memcached (sem espaços ou caracteres de
+// controle e com até 250 bytes); chaves inválidas são substituídas pelo seu hash
+func memcachedKey(key string) string {
+	valid := len(key) <= memcachedMaxKeyLength
+	for i := 0; valid && i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			valid = false
+		}
+	}
+	if valid {
+		return key
+	}
+
+	sum := sha1.Sum([]byte(key))
+	return "h:" + hex.EncodeToString(sum[:])
+}
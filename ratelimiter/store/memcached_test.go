@@ -0,0 +1,322 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMemcached é um servidor Memcached em processo que implementa o subconjunto do
+// protocolo texto usado pelo MemcachedStore (version, get, add, set, incr, decr e delete)
+type fakeMemcached struct {
+	listener net.Listener
+	mu       sync.Mutex
+	items    map[string]fakeMemcachedItem
+	offset   time.Duration
+}
+
+type fakeMemcachedItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+// newFakeMemcached inicia o servidor falso em uma porta local livre
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("falha ao iniciar servidor Memcached falso: %v", err)
+	}
+
+	f := &fakeMemcached{
+		listener: listener,
+		items:    make(map[string]fakeMemcachedItem),
+	}
+	go f.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return f
+}
+
+// advance avança o relógio do servidor, expirando itens sem esperar em tempo real
+func (f *fakeMemcached) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.offset += d
+}
+
+func (f *fakeMemcached) addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeMemcached) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeMemcached) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var reply string
+		switch fields[0] {
+		case "version":
+			reply = "VERSION 1.6.0-fake"
+		case "get":
+			reply = f.get(fields[1])
+		case "add", "set":
+			size, _ := strconv.Atoi(fields[4])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			exptime, _ := strconv.Atoi(fields[3])
+			reply = f.store(fields[0], fields[1], string(data[:size]), exptime)
+		case "incr":
+			delta, _ := strconv.Atoi(fields[2])
+			reply = f.incr(fields[1], delta)
+		case "decr":
+			delta, _ := strconv.Atoi(fields[2])
+			reply = f.incr(fields[1], -delta)
+		case "delete":
+			reply = f.delete(fields[1])
+		default:
+			reply = "ERROR"
+		}
+
+		if _, err := conn.Write([]byte(reply + "\r\n")); err != nil {
+			return
+		}
+	}
+}
+
+// lookup retorna um item não expirado. Deve ser chamado com o lock adquirido.
+func (f *fakeMemcached) lookup(key string) (fakeMemcachedItem, bool) {
+	item, ok := f.items[key]
+	if ok && !time.Now().Add(f.offset).Before(item.expiresAt) {
+		delete(f.items, key)
+		return item, false
+	}
+	return item, ok
+}
+
+func (f *fakeMemcached) get(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	item, ok := f.lookup(key)
+	if !ok {
+		return "END"
+	}
+	return fmt.Sprintf("VALUE %s 0 %d\r\n%s\r\nEND", key, len(item.value), item.value)
+}
+
+func (f *fakeMemcached) store(cmd, key, value string, exptime int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.lookup(key); exists && cmd == "add" {
+		return "NOT_STORED"
+	}
+	f.items[key] = fakeMemcachedItem{
+		value:     value,
+		expiresAt: time.Now().Add(f.offset).Add(time.Duration(exptime) * time.Second),
+	}
+	return "STORED"
+}
+
+func (f *fakeMemcached) incr(key string, delta int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	item, ok := f.lookup(key)
+	if !ok {
+		return "NOT_FOUND"
+	}
+	value, err := strconv.Atoi(item.value)
+	if err != nil {
+		return "CLIENT_ERROR cannot increment or decrement non-numeric value"
+	}
+	item.value = strconv.Itoa(max(value+delta, 0))
+	f.items[key] = item
+	return item.value
+}
+
+func (f *fakeMemcached) delete(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.lookup(key); !ok {
+		return "NOT_FOUND"
+	}
+	delete(f.items, key)
+	return "DELETED"
+}
+
+func TestMemcachedStore(t *testing.T) {
+	fake := newFakeMemcached(t)
+
+	s, err := NewMemcachedStore(MemcachedStoreOptions{Addr: fake.addr()})
+	if err != nil {
+		t.Fatalf("falha ao criar Memcached store: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+
+	t.Run("Counting", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			count, err := s.IncrementRequestCount(ctx, "ip:count", time.Minute)
+			if err != nil {
+				t.Fatalf("erro ao incrementar contador: %v", err)
+			}
+			if count != i {
+				t.Errorf("contagem deveria ser %d, mas recebeu %d", i, count)
+			}
+		}
+
+		count, err := s.GetRequestCount(ctx, "ip:count")
+		if err != nil {
+			t.Fatalf("erro ao obter contagem: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("contagem obtida deveria ser 3, mas recebeu %d", count)
+		}
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := s.IncrementRequestCount(ctx, "ip:concurrent", time.Minute); err != nil {
+					t.Errorf("erro ao incrementar contador: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if count, _ := s.GetRequestCount(ctx, "ip:concurrent"); count != 50 {
+			t.Errorf("contagem deveria ser 50, mas recebeu %d", count)
+		}
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		if _, err := s.IncrementRequestCount(ctx, "ip:expiry", time.Second); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+
+		fake.advance(2 * time.Second)
+
+		if count, _ := s.GetRequestCount(ctx, "ip:expiry"); count != 0 {
+			t.Errorf("contagem deveria ser 0 após expiração, mas recebeu %d", count)
+		}
+	})
+
+	t.Run("Block/Unblock", func(t *testing.T) {
+		if _, err := s.IncrementRequestCount(ctx, "token:block", time.Minute); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+		if err := s.Block(ctx, "token:block", 5*time.Second); err != nil {
+			t.Fatalf("erro ao bloquear chave: %v", err)
+		}
+
+		blocked, err := s.IsBlocked(ctx, "token:block")
+		if err != nil {
+			t.Fatalf("erro ao verificar bloqueio: %v", err)
+		}
+		if !blocked {
+			t.Error("chave deveria estar bloqueada após Block()")
+		}
+		if count, _ := s.GetRequestCount(ctx, "token:block"); count != 0 {
+			t.Errorf("contagem deveria ser 0 após bloqueio, mas recebeu %d", count)
+		}
+
+		fake.advance(6 * time.Second)
+
+		if blocked, _ := s.IsBlocked(ctx, "token:block"); blocked {
+			t.Error("chave não deveria estar bloqueada após expiração")
+		}
+	})
+
+	t.Run("Invalid keys", func(t *testing.T) {
+		// Tokens com espaços ou muito longos são convertidos em hash
+		key := "token:" + strings.Repeat("a b", 200)
+		count, err := s.IncrementRequestCount(ctx, key, time.Minute)
+		if err != nil {
+			t.Fatalf("erro ao incrementar contador com chave inválida: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("contagem deveria ser 1, mas recebeu %d", count)
+		}
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		if _, err := s.IncrementRequestCount(canceled, "ip:canceled", time.Minute); err != context.Canceled {
+			t.Errorf("esperava context.Canceled, mas recebeu %v", err)
+		}
+	})
+}
+
+func TestMemcachedStore_Close(t *testing.T) {
+	fake := newFakeMemcached(t)
+
+	s, err := NewMemcachedStore(MemcachedStoreOptions{Addr: fake.addr()})
+	if err != nil {
+		t.Fatalf("falha ao criar Memcached store: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("erro ao fechar store: %v", err)
+	}
+
+	// Após Close, nenhuma conexão é aberta nem devolvida ao pool
+	if _, err := s.IncrementRequestCount(context.Background(), "ip:closed", time.Minute); err != errMemcachedClosed {
+		t.Errorf("esperava errMemcachedClosed, mas recebeu %v", err)
+	}
+	if idle := len(s.idle); idle != 0 {
+		t.Errorf("pool deveria estar vazio após Close, mas tem %d conexões", idle)
+	}
+}
+
+func TestMemcachedExpiration(t *testing.T) {
+	if got := memcachedExpiration(time.Now(), 1500*time.Millisecond); got != 2 {
+		t.Errorf("1.5s deveria arredondar para 2 segundos, mas recebeu %d", got)
+	}
+	if got := memcachedExpiration(time.Now(), time.Millisecond); got != 1 {
+		t.Errorf("expiração mínima deveria ser 1 segundo, mas recebeu %d", got)
+	}
+
+	// Acima de 30 dias o Memcached espera um timestamp Unix
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := 60 * 24 * time.Hour
+	if got, want := memcachedExpiration(now, d), now.Add(d).Unix(); got != want {
+		t.Errorf("expiração longa deveria ser o timestamp %d, mas recebeu %d", want, got)
+	}
+}
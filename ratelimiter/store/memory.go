@@ -0,0 +1,537 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+)
+
+const (
+	// DefaultCleanupInterval é o intervalo padrão da rotina de limpeza
+	DefaultCleanupInterval = time.Minute
+
+	// evictionSampleSize é o número de chaves amostradas a cada evicção aproximada
+	evictionSampleSize = 5
+
+	// QuotaKeyPrefix é o prefixo das chaves de cota. A evicção só remove essas chaves
+	// quando a amostra não tem outra opção, pois removê-las reiniciaria a cota do cliente.
+	QuotaKeyPrefix = "quota:"
+)
+
+// MemoryStoreOptions configura o MemoryStore
+type MemoryStoreOptions struct {
+	// Intervalo entre execuções da rotina de limpeza (0 usa DefaultCleanupInterval)
+	CleanupInterval time.Duration
+	// Número máximo de chaves mantidas em memória (0 desativa o limite). O limite vale
+	// separadamente para contadores, reservas de concorrência e baldes de fichas.
+	MaxKeys int
+	// Relógio usado para expiração e bloqueios (nil usa o relógio do sistema)
+	Clock clock.Clock
+}
+
+// MemoryStoreStats contém estatísticas de ocupação do MemoryStore
+type MemoryStoreStats struct {
+	// Número total de chaves em memória, somando contadores e bloqueios, reservas de
+	// concorrência e baldes de fichas
+	Keys int
+	// Número de chaves com contador ativo
+	Counters int
+	// Número de chaves bloqueadas
+	Blocked int
+	// Número de chaves com reservas de concorrência
+	Leases int
+	// Número de chaves com balde de fichas do limite de banda
+	Buckets int
+	// Número máximo de chaves configurado (0 = sem limite)
+	MaxKeys int
+	// Total de chaves removidas por evicção desde a criação
+	Evictions uint64
+}
+
+// memoryEntry armazena o estado de uma chave em memória
+type memoryEntry struct {
+	count        int
+	expiresAt    time.Time
+	blockedUntil time.Time
+	lastAccess   time.Time
+}
+
+// MemoryStore implementa RateLimiterStore usando armazenamento em memória
+type MemoryStore struct {
+	entries   map[string]*memoryEntry
+	leases    map[string]map[string]time.Time
+	buckets   map[string]*tokenBucket
+	maxKeys   int
+	evictions uint64
+	clock     clock.Clock
+	mu        sync.RWMutex
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryStore cria uma nova instância de MemoryStore com as opções padrão
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithOptions(MemoryStoreOptions{})
+}
+
+// NewMemoryStoreWithOptions cria uma nova instância de MemoryStore e inicia a rotina de limpeza
+func NewMemoryStoreWithOptions(opts MemoryStoreOptions) *MemoryStore {
+	interval := opts.CleanupInterval
+	if interval <= 0 {
+		interval = DefaultCleanupInterval
+	}
+
+	s := &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		leases:  make(map[string]map[string]time.Time),
+		buckets: make(map[string]*tokenBucket),
+		maxKeys: opts.MaxKeys,
+		clock:   clock.OrDefault(opts.Clock),
+		stop:    make(chan struct{}),
+	}
+	s.startCleanupRoutine(interval)
+
+	return s
+}
+
+// GetRequestCount obtém o número atual de requisições para uma chave
+func (s *MemoryStore) GetRequestCount(ctx context.Context, key string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !s.clock.Now().Before(entry.expiresAt) {
+		// Chave inexistente ou janela expirada
+		return 0, nil
+	}
+
+	return entry.count, nil
+}
+
+// IncrementRequestCount incrementa o contador de requisições para uma chave
+func (s *MemoryStore) IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error) {
+	return s.IncrementRequestCountBy(ctx, key, 1, expiration)
+}
+
+// IncrementRequestCountBy soma amount ao contador de requisições de uma chave
+func (s *MemoryStore) IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error) {
+	count, _, err := s.IncrementRequestCountTTL(ctx, key, amount, expiration)
+	return count, err
+}
+
+// IncrementRequestCountTTL soma amount ao contador e retorna também o tempo até o fim da janela
+func (s *MemoryStore) IncrementRequestCountTTL(ctx context.Context, key string, amount int, expiration time.Duration) (int, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if amount < 0 {
+		// Uma devolução sem janela ativa não tem o que devolver: criar a janela com um
+		// contador negativo daria crédito à próxima janela
+		entry, ok := s.entries[key]
+		if !ok || !now.Before(entry.expiresAt) {
+			return 0, 0, nil
+		}
+	}
+
+	entry := s.getOrCreate(key, now)
+	if !now.Before(entry.expiresAt) {
+		entry.count = 0
+		entry.expiresAt = now.Add(expiration)
+	}
+	entry.count = max(entry.count+amount, 0)
+
+	return entry.count, entry.expiresAt.Sub(now), nil
+}
+
+// GetRequestCountTTL obtém a contagem atual e o tempo até o fim da janela de uma chave
+func (s *MemoryStore) GetRequestCountTTL(ctx context.Context, key string) (int, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return 0, 0, nil
+	}
+
+	return entry.count, entry.expiresAt.Sub(now), nil
+}
+
+// IsBlocked verifica se uma chave está bloqueada
+func (s *MemoryStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[key]
+	return ok && s.clock.Now().Before(entry.blockedUntil), nil
+}
+
+// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar
+func (s *MemoryStore) BlockTTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	if ttl := entry.blockedUntil.Sub(s.clock.Now()); ttl > 0 {
+		return ttl, nil
+	}
+	return 0, nil
+}
+
+// Block bloqueia uma chave pelo tempo de bloqueio especificado
+func (s *MemoryStore) Block(ctx context.Context, key string, blockTime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	entry := s.getOrCreate(key, now)
+	entry.blockedUntil = now.Add(blockTime)
+
+	// Resetamos o contador quando bloqueamos
+	entry.count = 0
+	entry.expiresAt = time.Time{}
+
+	return nil
+}
+
+// Acquire tenta reservar uma das limit vagas simultâneas da chave
+func (s *MemoryStore) Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	leases := s.leases[key]
+	for lease, expiresAt := range leases {
+		if !now.Before(expiresAt) {
+			delete(leases, lease)
+		}
+	}
+
+	if len(leases) >= limit {
+		return "", false, nil
+	}
+
+	if leases == nil {
+		if s.maxKeys > 0 && len(s.leases) >= s.maxKeys {
+			s.evictLeases(now)
+		}
+		leases = make(map[string]time.Time)
+		s.leases[key] = leases
+	}
+	lease := newLeaseID()
+	leases[lease] = now.Add(ttl)
+
+	return lease, true, nil
+}
+
+// Renew estende a expiração de uma reserva ainda válida
+func (s *MemoryStore) Renew(ctx context.Context, key string, lease string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	expiresAt, ok := s.leases[key][lease]
+	if !ok || !now.Before(expiresAt) {
+		return false, nil
+	}
+	s.leases[key][lease] = now.Add(ttl)
+
+	return true, nil
+}
+
+// Release libera a vaga de uma reserva
+func (s *MemoryStore) Release(ctx context.Context, key string, lease string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.leases[key], lease)
+	if len(s.leases[key]) == 0 {
+		delete(s.leases, key)
+	}
+
+	return nil
+}
+
+// Reserve consome n fichas do balde da chave e retorna a espera até que estejam disponíveis
+func (s *MemoryStore) Reserve(ctx context.Context, key string, n int, rate float64, burst int) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		if s.maxKeys > 0 && len(s.buckets) >= s.maxKeys {
+			s.evictBucket(now)
+		}
+		bucket = &tokenBucket{}
+		s.buckets[key] = bucket
+	}
+
+	return bucket.reserve(now, n, rate, burst), nil
+}
+
+// Stats retorna estatísticas de ocupação do armazenamento
+func (s *MemoryStore) Stats() MemoryStoreStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	stats := MemoryStoreStats{
+		Keys:      len(s.entries) + len(s.leases) + len(s.buckets),
+		Leases:    len(s.leases),
+		Buckets:   len(s.buckets),
+		MaxKeys:   s.maxKeys,
+		Evictions: s.evictions,
+	}
+	for _, entry := range s.entries {
+		if now.Before(entry.expiresAt) {
+			stats.Counters++
+		}
+		if now.Before(entry.blockedUntil) {
+			stats.Blocked++
+		}
+	}
+
+	return stats
+}
+
+// Close encerra a rotina de limpeza do armazenamento
+func (s *MemoryStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	return nil
+}
+
+// getOrCreate retorna a entrada de uma chave, criando-a (e aplicando o limite de chaves) se necessário.
+// Deve ser chamado com o lock de escrita adquirido.
+func (s *MemoryStore) getOrCreate(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		if s.maxKeys > 0 && len(s.entries) >= s.maxKeys {
+			s.evict(now)
+		}
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	entry.lastAccess = now
+
+	return entry
+}
+
+// evict libera espaço para uma nova chave usando um LRU aproximado: amostra algumas
+// chaves e remove a de menor prioridade, na ordem expirada, com contador ativo,
+// bloqueada e de cota; entre chaves de mesma prioridade, remove a acessada há mais
+// tempo. A amostra tem tamanho fixo, então o custo não cresce com o número de chaves.
+// Deve ser chamado com o lock de escrita adquirido.
+func (s *MemoryStore) evict(now time.Time) {
+	var (
+		victim      string
+		victimEntry *memoryEntry
+		victimRank  int
+		sampled     int
+	)
+	// A iteração de maps em Go é aleatória, o que fornece a amostragem
+	for key, entry := range s.entries {
+		rank := evictionRank(key, entry, now)
+		if victimEntry == nil || rank < victimRank ||
+			(rank == victimRank && entry.lastAccess.Before(victimEntry.lastAccess)) {
+			victim, victimEntry, victimRank = key, entry, rank
+		}
+
+		sampled++
+		if sampled >= evictionSampleSize {
+			break
+		}
+	}
+
+	if victimEntry != nil {
+		delete(s.entries, victim)
+		s.evictions++
+	}
+}
+
+// evictionRank ordena as entradas pela preferência de remoção (menor é removida antes)
+func evictionRank(key string, entry *memoryEntry, now time.Time) int {
+	switch {
+	case strings.HasPrefix(key, QuotaKeyPrefix) && now.Before(entry.expiresAt):
+		return 3
+	case now.Before(entry.blockedUntil):
+		return 2
+	case now.Before(entry.expiresAt):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// evictLeases libera espaço para as reservas de uma nova chave: remove as reservas
+// expiradas das chaves amostradas e, se nenhuma ficar vazia, descarta a chave cujas
+// reservas expiram primeiro.
+// Deve ser chamado com o lock de escrita adquirido.
+func (s *MemoryStore) evictLeases(now time.Time) {
+	var (
+		victim       string
+		victimExpiry time.Time
+		sampled      int
+	)
+	for key, leases := range s.leases {
+		var latest time.Time
+		for lease, expiresAt := range leases {
+			if !now.Before(expiresAt) {
+				delete(leases, lease)
+			} else if expiresAt.After(latest) {
+				latest = expiresAt
+			}
+		}
+		if len(leases) == 0 {
+			delete(s.leases, key)
+			return
+		}
+		if victim == "" || latest.Before(victimExpiry) {
+			victim, victimExpiry = key, latest
+		}
+
+		sampled++
+		if sampled >= evictionSampleSize {
+			break
+		}
+	}
+
+	if victim != "" {
+		delete(s.leases, victim)
+		s.evictions++
+	}
+}
+
+// evictBucket libera espaço para o balde de uma nova chave, descartando entre as chaves
+// amostradas o balde que volta a ficar cheio primeiro (descartar um balde equivale a
+// reabastecê-lo por completo).
+// Deve ser chamado com o lock de escrita adquirido.
+func (s *MemoryStore) evictBucket(now time.Time) {
+	var (
+		victim       string
+		victimBucket *tokenBucket
+		sampled      int
+	)
+	for key, bucket := range s.buckets {
+		if victimBucket == nil || bucket.fullAt.Before(victimBucket.fullAt) {
+			victim, victimBucket = key, bucket
+		}
+		if !now.Before(bucket.fullAt) {
+			break
+		}
+
+		sampled++
+		if sampled >= evictionSampleSize {
+			break
+		}
+	}
+
+	if victimBucket != nil {
+		delete(s.buckets, victim)
+		s.evictions++
+	}
+}
+
+// Rotina de limpeza de dados expirados (não é necessária para o Redis, que tem seu próprio mecanismo de expiração)
+func (s *MemoryStore) startCleanupRoutine(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanup()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Limpa dados expirados
+func (s *MemoryStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpired(s.clock.Now())
+}
+
+// removeExpired remove entradas cujo contador e bloqueio já expiraram.
+// Deve ser chamado com o lock de escrita adquirido.
+func (s *MemoryStore) removeExpired(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) && !now.Before(entry.blockedUntil) {
+			delete(s.entries, key)
+		}
+	}
+
+	// Remove reservas de concorrência que expiraram sem Release
+	for key, leases := range s.leases {
+		for lease, expiresAt := range leases {
+			if !now.Before(expiresAt) {
+				delete(leases, lease)
+			}
+		}
+		if len(leases) == 0 {
+			delete(s.leases, key)
+		}
+	}
+
+	// Remove baldes de fichas que já voltaram a ficar cheios
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
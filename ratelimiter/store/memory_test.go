@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_MaxKeys(t *testing.T) {
+	// Cria store com limite de chaves
+	s := NewMemoryStoreWithOptions(MemoryStoreOptions{MaxKeys: 10})
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Simula tráfego de varredura com muitos IPs distintos
+	for i := 0; i < 100; i++ {
+		if _, err := s.IncrementRequestCount(ctx, fmt.Sprintf("ip:10.0.0.%d", i), time.Minute); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+	}
+
+	stats := s.Stats()
+	if stats.Keys > 10 {
+		t.Errorf("número de chaves deveria ser no máximo 10, mas recebeu %d", stats.Keys)
+	}
+	if stats.Evictions != 90 {
+		t.Errorf("deveria ter removido 90 chaves, mas removeu %d", stats.Evictions)
+	}
+}
+
+func TestMemoryStore_EvictionKeepsBlockedKeys(t *testing.T) {
+	// Cria store com limite de chaves
+	s := NewMemoryStoreWithOptions(MemoryStoreOptions{MaxKeys: 3})
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Bloqueia uma chave e preenche o restante do espaço
+	if err := s.Block(ctx, "ip:blocked", time.Minute); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := s.IncrementRequestCount(ctx, fmt.Sprintf("ip:%d", i), time.Minute); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+	}
+
+	// A chave bloqueada não deve ter sido removida
+	blocked, err := s.IsBlocked(ctx, "ip:blocked")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio: %v", err)
+	}
+	if !blocked {
+		t.Error("chave bloqueada não deveria ser removida pela evicção")
+	}
+}
+
+func TestMemoryStore_Cleanup(t *testing.T) {
+	// Cria store com limpeza frequente
+	s := NewMemoryStoreWithOptions(MemoryStoreOptions{CleanupInterval: 10 * time.Millisecond})
+	defer s.Close()
+
+	ctx := context.Background()
+
+	if _, err := s.IncrementRequestCount(ctx, "ip:1", 20*time.Millisecond); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if err := s.Block(ctx, "ip:2", 20*time.Millisecond); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+
+	// Espera as entradas expirarem e a rotina de limpeza executar
+	deadline := time.Now().Add(time.Second)
+	for s.Stats().Keys > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if keys := s.Stats().Keys; keys != 0 {
+		t.Errorf("rotina de limpeza deveria remover as chaves expiradas, mas restaram %d", keys)
+	}
+}
+
+func TestMemoryStore_CloseIsIdempotent(t *testing.T) {
+	s := NewMemoryStore()
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("erro ao fechar store: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("erro ao fechar store pela segunda vez: %v", err)
+	}
+}
+
+func TestMemoryStore_EvictionKeepsQuotaKeys(t *testing.T) {
+	s := NewMemoryStoreWithOptions(MemoryStoreOptions{MaxKeys: 3})
+	defer s.Close()
+
+	ctx := context.Background()
+
+	if _, err := s.IncrementRequestCountBy(ctx, QuotaKeyPrefix+"monthly:ip:1", 7, 30*24*time.Hour); err != nil {
+		t.Fatalf("erro ao incrementar cota: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := s.IncrementRequestCount(ctx, fmt.Sprintf("ip:%d", i), time.Minute); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+	}
+
+	// A cota não deve ter sido reiniciada pela evicção
+	count, err := s.GetRequestCount(ctx, QuotaKeyPrefix+"monthly:ip:1")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 7 {
+		t.Errorf("contador de cota deveria ser mantido em 7, mas recebeu %d", count)
+	}
+}
+
+func TestMemoryStore_MaxKeysBoundsLeasesAndBuckets(t *testing.T) {
+	s := NewMemoryStoreWithOptions(MemoryStoreOptions{MaxKeys: 10})
+	defer s.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		if _, _, err := s.Acquire(ctx, fmt.Sprintf("concurrency:ip:%d", i), 1, time.Minute); err != nil {
+			t.Fatalf("erro ao reservar vaga: %v", err)
+		}
+		if _, err := s.Reserve(ctx, fmt.Sprintf("bandwidth:ip:%d", i), 10, 1, 100); err != nil {
+			t.Fatalf("erro ao reservar fichas: %v", err)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.leases) > 10 {
+		t.Errorf("reservas deveriam ficar limitadas a 10 chaves, mas recebeu %d", len(s.leases))
+	}
+	if len(s.buckets) > 10 {
+		t.Errorf("baldes deveriam ficar limitados a 10 chaves, mas recebeu %d", len(s.buckets))
+	}
+}
+
+func TestMemoryStore_StatsIncludesLeasesAndBuckets(t *testing.T) {
+	s := NewMemoryStore()
+	defer s.Close()
+
+	ctx := context.Background()
+
+	if _, err := s.IncrementRequestCount(ctx, "ip:1", time.Minute); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if _, _, err := s.Acquire(ctx, "concurrency:ip:1", 1, time.Minute); err != nil {
+		t.Fatalf("erro ao reservar vaga: %v", err)
+	}
+	if _, err := s.Reserve(ctx, "bandwidth:ip:1", 10, 100, 100); err != nil {
+		t.Fatalf("erro ao reservar fichas: %v", err)
+	}
+
+	stats := s.Stats()
+	if stats.Keys != 3 || stats.Counters != 1 || stats.Leases != 1 || stats.Buckets != 1 {
+		t.Errorf("estatísticas deveriam contar 3 chaves (1 contador, 1 reserva e 1 balde), mas recebeu %+v", stats)
+	}
+}
@@ -0,0 +1,297 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStoreOptions configura a conexão do RedisStore
+type RedisStoreOptions struct {
+	// Endereço do servidor (host:porta)
+	Addr string
+	// Senha do Redis (opcional)
+	Password string
+	// Número do banco de dados
+	DB int
+}
+
+// RedisStore implementa RateLimiterStore usando Redis
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore cria uma nova instância de RedisStore
+func NewRedisStore(opts RedisStoreOptions) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     opts.Addr,
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+
+	// Testa a conexão
+	ctx := context.Background()
+	_, err := client.Ping(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao conectar com Redis: %w", err)
+	}
+
+	return &RedisStore{
+		client: client,
+	}, nil
+}
+
+// GetRequestCount obtém o número atual de requisições para uma chave
+func (s *RedisStore) GetRequestCount(ctx context.Context, key string) (int, error) {
+	countKey := fmt.Sprintf("count:%s", key)
+	val, err := s.client.Get(ctx, countKey).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// incrementScript soma a quantidade ao contador e, se ele acabou de ser criado, define a
+// expiração em milissegundos no mesmo comando atômico. Retorna a contagem e o TTL restante.
+// Uma quantidade negativa (devolução) só altera um contador existente e não o deixa
+// negativo; sem contador, não há o que devolver e nenhuma chave é criada. A expiração só
+// é definida quando a chave não tem TTL, então uma devolução que traz o contador de volta
+// ao valor inicial não estende a janela.
+var incrementScript = redis.NewScript(`
+local amount = tonumber(ARGV[1])
+if amount < 0 then
+	local current = tonumber(redis.call("GET", KEYS[1]))
+	if not current then
+		return {0, 0}
+	end
+	if current + amount < 0 then
+		amount = -current
+	end
+end
+local count = redis.call("INCRBY", KEYS[1], amount)
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return {count, redis.call("PTTL", KEYS[1])}
+`)
+
+// IncrementRequestCount incrementa o contador de requisições para uma chave
+func (s *RedisStore) IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error) {
+	return s.IncrementRequestCountBy(ctx, key, 1, expiration)
+}
+
+// IncrementRequestCountBy soma amount ao contador de requisições de uma chave
+func (s *RedisStore) IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error) {
+	count, _, err := s.IncrementRequestCountTTL(ctx, key, amount, expiration)
+	return count, err
+}
+
+// IncrementRequestCountTTL soma amount ao contador e retorna também o tempo até o fim da janela
+func (s *RedisStore) IncrementRequestCountTTL(ctx context.Context, key string, amount int, expiration time.Duration) (int, time.Duration, error) {
+	countKey := fmt.Sprintf("count:%s", key)
+
+	// PEXPIRE preserva janelas menores que um segundo, que EXPIRE truncaria
+	vals, err := incrementScript.Run(ctx, s.client, []string{countKey}, amount, expiration.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(vals) != 2 {
+		return 0, 0, fmt.Errorf("resposta inesperada do script de incremento: %v", vals)
+	}
+
+	return int(vals[0]), max(time.Duration(vals[1])*time.Millisecond, 0), nil
+}
+
+// GetRequestCountTTL obtém a contagem atual e o tempo até o fim da janela de uma chave
+func (s *RedisStore) GetRequestCountTTL(ctx context.Context, key string) (int, time.Duration, error) {
+	countKey := fmt.Sprintf("count:%s", key)
+
+	pipe := s.client.Pipeline()
+	get := pipe.Get(ctx, countKey)
+	pttl := pipe.PTTL(ctx, countKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+
+	count, err := get.Int()
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// PTTL retorna valores negativos quando a chave não existe
+	return count, max(pttl.Val(), 0), nil
+}
+
+// IsBlocked verifica se uma chave está bloqueada
+func (s *RedisStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	blockKey := fmt.Sprintf("blocked:%s", key)
+	exists, err := s.client.Exists(ctx, blockKey).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return exists > 0, nil
+}
+
+// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar
+func (s *RedisStore) BlockTTL(ctx context.Context, key string) (time.Duration, error) {
+	blockKey := fmt.Sprintf("blocked:%s", key)
+	ttl, err := s.client.PTTL(ctx, blockKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// PTTL retorna valores negativos quando a chave não existe
+	return max(ttl, 0), nil
+}
+
+// Block bloqueia uma chave pelo tempo de bloqueio especificado
+func (s *RedisStore) Block(ctx context.Context, key string, blockTime time.Duration) error {
+	blockKey := fmt.Sprintf("blocked:%s", key)
+	_, err := s.client.Set(ctx, blockKey, "1", blockTime).Result()
+	if err != nil {
+		return err
+	}
+
+	// Resetamos o contador quando bloqueamos
+	countKey := fmt.Sprintf("count:%s", key)
+	_, err = s.client.Del(ctx, countKey).Result()
+	return err
+}
+
+// acquireScript remove as reservas expiradas do ZSET da chave (score = instante de
+// expiração em milissegundos) e, se houver vaga, adiciona a nova reserva. O instante
+// atual vem do TIME do Redis, comum a todas as instâncias.
+var acquireScript = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local ttl = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + ttl, ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ttl)
+return 1
+`)
+
+// renewScript estende a expiração de uma reserva que ainda não expirou, usando o TIME do
+// Redis como instante atual
+var renewScript = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local ttl = tonumber(ARGV[2])
+
+local expiresAt = tonumber(redis.call("ZSCORE", KEYS[1], ARGV[1]))
+if expiresAt == nil or expiresAt <= now then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + ttl, ARGV[1])
+if redis.call("PTTL", KEYS[1]) < ttl then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1
+`)
+
+// Acquire tenta reservar uma das limit vagas simultâneas da chave. As reservas ficam em
+// um ZSET com o instante de expiração como score, então reservas de instâncias que
+// pararam sem Release expiram sozinhas. A expiração é calculada com o relógio do Redis,
+// de modo que instâncias com relógios divergentes não liberam vagas umas das outras.
+func (s *RedisStore) Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (string, bool, error) {
+	leaseKey := fmt.Sprintf("leases:%s", key)
+	lease := newLeaseID()
+
+	ok, err := acquireScript.Run(ctx, s.client, []string{leaseKey},
+		limit, lease, ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return "", false, err
+	}
+	if ok == 0 {
+		return "", false, nil
+	}
+
+	return lease, true, nil
+}
+
+// Renew estende a expiração de uma reserva ainda válida
+func (s *RedisStore) Renew(ctx context.Context, key string, lease string, ttl time.Duration) (bool, error) {
+	leaseKey := fmt.Sprintf("leases:%s", key)
+
+	ok, err := renewScript.Run(ctx, s.client, []string{leaseKey}, lease, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+// Release libera a vaga de uma reserva
+func (s *RedisStore) Release(ctx context.Context, key string, lease string) error {
+	leaseKey := fmt.Sprintf("leases:%s", key)
+	return s.client.ZRem(ctx, leaseKey, lease).Err()
+}
+
+// reserveScript reabastece o balde de fichas da chave (hash com o saldo e o instante da
+// última atualização em milissegundos), consome as fichas e retorna a espera em
+// microssegundos. O instante atual vem do TIME do Redis, então instâncias com relógios
+// divergentes compartilham o mesmo balde sem reabastecê-lo a mais. O hash expira quando
+// o balde voltaria a ficar cheio.
+var reserveScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+elseif now > updated then
+	tokens = math.min(burst, tokens + (now - updated) * rate / 1000)
+end
+tokens = tokens - n
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1)
+
+if tokens >= 0 then
+	return 0
+end
+return math.ceil(-tokens * 1000000 / rate)
+`)
+
+// Reserve consome n fichas do balde da chave e retorna a espera até que estejam disponíveis
+func (s *RedisStore) Reserve(ctx context.Context, key string, n int, rate float64, burst int) (time.Duration, error) {
+	bucketKey := fmt.Sprintf("bucket:%s", key)
+	wait, err := reserveScript.Run(ctx, s.client, []string{bucketKey},
+		rate, burst, n,
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(wait) * time.Microsecond, nil
+}
+
+// Close fecha a conexão com o Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -5,8 +5,6 @@ import (
 	"os"
 	"testing"
 	"time"
-
-	"github.com/pperesbr/ratelimiter/config"
 )
 
 // TestRedisStore_Integration é um teste de integração que requer uma instância Redis em execução
@@ -18,15 +16,14 @@ func TestRedisStore_Integration(t *testing.T) {
 	}
 
 	// Configura conexão Redis
-	cfg := &config.Config{
-		RedisHost:     getEnvOrDefault("REDIS_HOST", "localhost"),
-		RedisPort:     getEnvOrDefault("REDIS_PORT", "6379"),
-		RedisPassword: getEnvOrDefault("REDIS_PASSWORD", ""),
-		RedisDB:       0,
+	opts := RedisStoreOptions{
+		Addr:     getEnvOrDefault("REDIS_HOST", "localhost") + ":" + getEnvOrDefault("REDIS_PORT", "6379"),
+		Password: getEnvOrDefault("REDIS_PASSWORD", ""),
+		DB:       0,
 	}
 
 	// Cria store Redis
-	redisStore, err := NewRedisStore(cfg)
+	redisStore, err := NewRedisStore(opts)
 	if err != nil {
 		t.Fatalf("falha ao criar Redis store: %v", err)
 	}
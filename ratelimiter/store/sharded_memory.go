@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// ShardedMemoryStore implementa RateLimiterStore particionando as chaves entre vários
+// MemoryStore, cada um com seu próprio lock, para reduzir a contenção em máquinas com muitos núcleos
+type ShardedMemoryStore struct {
+	shards []*MemoryStore
+}
+
+// NewShardedMemoryStore cria um armazenamento em memória com o número de partições informado.
+// Se shards for menor ou igual a zero, usa uma partição por CPU. O limite de chaves de
+// opts.MaxKeys é dividido igualmente entre as partições.
+func NewShardedMemoryStore(shards int, opts MemoryStoreOptions) *ShardedMemoryStore {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+
+	shardOpts := opts
+	if opts.MaxKeys > 0 {
+		shardOpts.MaxKeys = (opts.MaxKeys + shards - 1) / shards
+	}
+
+	s := &ShardedMemoryStore{
+		shards: make([]*MemoryStore, shards),
+	}
+	for i := range s.shards {
+		s.shards[i] = NewMemoryStoreWithOptions(shardOpts)
+	}
+
+	return s
+}
+
+// GetRequestCount obtém o número atual de requisições para uma chave
+func (s *ShardedMemoryStore) GetRequestCount(ctx context.Context, key string) (int, error) {
+	return s.shard(key).GetRequestCount(ctx, key)
+}
+
+// IncrementRequestCount incrementa o contador de requisições para uma chave
+func (s *ShardedMemoryStore) IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error) {
+	return s.shard(key).IncrementRequestCount(ctx, key, expiration)
+}
+
+// IncrementRequestCountBy soma amount ao contador de requisições de uma chave
+func (s *ShardedMemoryStore) IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error) {
+	return s.shard(key).IncrementRequestCountBy(ctx, key, amount, expiration)
+}
+
+// IncrementRequestCountTTL soma amount ao contador e retorna também o tempo até o fim da janela
+func (s *ShardedMemoryStore) IncrementRequestCountTTL(ctx context.Context, key string, amount int, expiration time.Duration) (int, time.Duration, error) {
+	return s.shard(key).IncrementRequestCountTTL(ctx, key, amount, expiration)
+}
+
+// GetRequestCountTTL obtém a contagem atual e o tempo até o fim da janela de uma chave
+func (s *ShardedMemoryStore) GetRequestCountTTL(ctx context.Context, key string) (int, time.Duration, error) {
+	return s.shard(key).GetRequestCountTTL(ctx, key)
+}
+
+// IsBlocked verifica se uma chave está bloqueada
+func (s *ShardedMemoryStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	return s.shard(key).IsBlocked(ctx, key)
+}
+
+// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar
+func (s *ShardedMemoryStore) BlockTTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.shard(key).BlockTTL(ctx, key)
+}
+
+// Block bloqueia uma chave pelo tempo de bloqueio especificado
+func (s *ShardedMemoryStore) Block(ctx context.Context, key string, blockTime time.Duration) error {
+	return s.shard(key).Block(ctx, key, blockTime)
+}
+
+// Acquire tenta reservar uma das limit vagas simultâneas da chave
+func (s *ShardedMemoryStore) Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (string, bool, error) {
+	return s.shard(key).Acquire(ctx, key, limit, ttl)
+}
+
+// Renew estende a expiração de uma reserva ainda válida
+func (s *ShardedMemoryStore) Renew(ctx context.Context, key string, lease string, ttl time.Duration) (bool, error) {
+	return s.shard(key).Renew(ctx, key, lease, ttl)
+}
+
+// Release libera a vaga de uma reserva
+func (s *ShardedMemoryStore) Release(ctx context.Context, key string, lease string) error {
+	return s.shard(key).Release(ctx, key, lease)
+}
+
+// Reserve consome n fichas do balde da chave e retorna a espera até que estejam disponíveis
+func (s *ShardedMemoryStore) Reserve(ctx context.Context, key string, n int, rate float64, burst int) (time.Duration, error) {
+	return s.shard(key).Reserve(ctx, key, n, rate, burst)
+}
+
+// Stats retorna as estatísticas somadas de todas as partições
+func (s *ShardedMemoryStore) Stats() MemoryStoreStats {
+	var total MemoryStoreStats
+	for _, shard := range s.shards {
+		stats := shard.Stats()
+		total.Keys += stats.Keys
+		total.Counters += stats.Counters
+		total.Blocked += stats.Blocked
+		total.Leases += stats.Leases
+		total.Buckets += stats.Buckets
+		total.MaxKeys += stats.MaxKeys
+		total.Evictions += stats.Evictions
+	}
+
+	return total
+}
+
+// Close encerra as rotinas de limpeza de todas as partições
+func (s *ShardedMemoryStore) Close() error {
+	for _, shard := range s.shards {
+		shard.Close()
+	}
+	return nil
+}
+
+// shard retorna a partição responsável por uma chave usando o hash FNV-1a
+func (s *ShardedMemoryStore) shard(key string) *MemoryStore {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	hash := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+
+	return s.shards[hash%uint32(len(s.shards))]
+}
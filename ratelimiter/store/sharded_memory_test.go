@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShardedMemoryStore_Concurrency(t *testing.T) {
+	s := NewShardedMemoryStore(8, MemoryStoreOptions{})
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Várias goroutines incrementam as mesmas chaves
+	const workers, perWorker, keys = 16, 100, 4
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				key := fmt.Sprintf("ip:%d", i%keys)
+				if _, err := s.IncrementRequestCount(ctx, key, time.Minute); err != nil {
+					t.Errorf("erro ao incrementar contador: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Nenhum incremento pode ser perdido
+	for k := 0; k < keys; k++ {
+		count, err := s.GetRequestCount(ctx, fmt.Sprintf("ip:%d", k))
+		if err != nil {
+			t.Fatalf("erro ao obter contagem: %v", err)
+		}
+		if want := workers * perWorker / keys; count != want {
+			t.Errorf("contagem da chave %d deveria ser %d, mas recebeu %d", k, want, count)
+		}
+	}
+
+	if stats := s.Stats(); stats.Keys != keys {
+		t.Errorf("deveria haver %d chaves, mas recebeu %d", keys, stats.Keys)
+	}
+}
+
+func TestShardedMemoryStore_MaxKeys(t *testing.T) {
+	s := NewShardedMemoryStore(4, MemoryStoreOptions{MaxKeys: 40})
+	defer s.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		if _, err := s.IncrementRequestCount(ctx, fmt.Sprintf("ip:%d", i), time.Minute); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+	}
+
+	if stats := s.Stats(); stats.Keys > 40 {
+		t.Errorf("número de chaves deveria ser no máximo 40, mas recebeu %d", stats.Keys)
+	}
+}
+
+// benchmarkIncrement mede a vazão de IncrementRequestCount com goroutines concorrentes
+// distribuídas por muitas chaves, como acontece com tráfego de vários clientes
+func benchmarkIncrement(b *testing.B, s RateLimiterStore) {
+	defer s.Close()
+
+	const keys = 1024
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = fmt.Sprintf("ip:10.0.%d.%d", i/256, i%256)
+	}
+
+	ctx := context.Background()
+	var seq uint64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := atomic.AddUint64(&seq, 7919)
+		for pb.Next() {
+			i++
+			if _, err := s.IncrementRequestCount(ctx, names[i%keys], time.Minute); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// Compare com: go test -bench Increment -cpu 1,4,8,16 ./ratelimiter/store/
+func BenchmarkMemoryStore_Increment(b *testing.B) {
+	benchmarkIncrement(b, NewMemoryStore())
+}
+
+func BenchmarkShardedMemoryStore_Increment(b *testing.B) {
+	benchmarkIncrement(b, NewShardedMemoryStore(0, MemoryStoreOptions{}))
+}
@@ -0,0 +1,525 @@
+package store
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/clock"
+)
+
+const (
+	// DefaultPurgeInterval é o intervalo padrão da remoção de linhas expiradas do SQLStore
+	DefaultPurgeInterval = time.Minute
+
+	// sqlMaxKeyLength é o tamanho da coluna rl_key (VARCHAR(255))
+	sqlMaxKeyLength = 255
+)
+
+// Dialetos SQL suportados pelo SQLStore
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// SQLStoreOptions configura o SQLStore
+type SQLStoreOptions struct {
+	// Dialeto do banco de dados (postgres, mysql ou sqlite)
+	Dialect string
+	// Intervalo entre remoções de linhas expiradas (0 usa DefaultPurgeInterval)
+	PurgeInterval time.Duration
+	// Relógio usado para expiração e bloqueios (nil usa o relógio do sistema)
+	Clock clock.Clock
+}
+
+// sqlDialect agrupa as diferenças de sintaxe entre os bancos suportados
+type sqlDialect struct {
+	// Placeholders numerados ($1, $2...) em vez de ?
+	numberedPlaceholders bool
+	// Suporte a INSERT ... RETURNING
+	returning bool
+	// DDL transacional: sem ele (MySQL), cada DDL faz commit implícito da transação
+	transactionalDDL bool
+	// Upsert que inicia ou incrementa o contador. Parâmetros: chave, quantidade, expiração da
+	// nova janela, agora, quantidade, agora
+	upsertCount string
+	// Upsert do bloqueio. Parâmetros: chave, fim do bloqueio
+	upsertBlock string
+	// Migrações do schema, aplicadas em ordem
+	migrations []string
+	// Registro de uma migração aplicada, ignorado se outra instância já a registrou.
+	// Parâmetro: versão
+	insertMigration string
+	// Adquire e libera um lock exclusivo entre instâncias durante as migrações (vazio no
+	// SQLite, que já serializa as escritas no arquivo). O lock retorna 1 quando adquirido.
+	lockMigrations   string
+	unlockMigrations string
+}
+
+// Migrações do PostgreSQL e do SQLite. Os instantes são gravados em milissegundos Unix
+// (BIGINT) para manter o schema portável, e cada migração pode ser reaplicada sem erro.
+var sqlMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS ratelimiter_counts (
+		rl_key VARCHAR(255) NOT NULL PRIMARY KEY,
+		count BIGINT NOT NULL,
+		expires_at BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS ratelimiter_blocks (
+		rl_key VARCHAR(255) NOT NULL PRIMARY KEY,
+		blocked_until BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_ratelimiter_counts_expires_at ON ratelimiter_counts (expires_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_ratelimiter_blocks_blocked_until ON ratelimiter_blocks (blocked_until)`,
+}
+
+// Migrações do MySQL, que não aceita CREATE INDEX IF NOT EXISTS. A reaplicação é evitada
+// pelo lock de migrações e pelo registro gravado só depois do DDL.
+var mysqlMigrations = []string{
+	sqlMigrations[0],
+	sqlMigrations[1],
+	`CREATE INDEX idx_ratelimiter_counts_expires_at ON ratelimiter_counts (expires_at)`,
+	`CREATE INDEX idx_ratelimiter_blocks_blocked_until ON ratelimiter_blocks (blocked_until)`,
+}
+
+// Registro de migração no padrão ON CONFLICT, usado por PostgreSQL e SQLite
+const onConflictInsertMigration = `INSERT INTO ratelimiter_schema_migrations (version) VALUES (?)
+	ON CONFLICT (version) DO NOTHING`
+
+// Upserts no padrão ON CONFLICT, usados por PostgreSQL e SQLite
+const (
+	onConflictUpsertCount = `INSERT INTO ratelimiter_counts (rl_key, count, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (rl_key) DO UPDATE SET
+			count = CASE WHEN ratelimiter_counts.expires_at <= ? THEN excluded.count ELSE ratelimiter_counts.count + ? END,
+			expires_at = CASE WHEN ratelimiter_counts.expires_at <= ? THEN excluded.expires_at ELSE ratelimiter_counts.expires_at END
+		RETURNING count, expires_at`
+	onConflictUpsertBlock = `INSERT INTO ratelimiter_blocks (rl_key, blocked_until) VALUES (?, ?)
+		ON CONFLICT (rl_key) DO UPDATE SET blocked_until = excluded.blocked_until`
+)
+
+var sqlDialects = map[string]sqlDialect{
+	DialectPostgres: {
+		numberedPlaceholders: true,
+		returning:            true,
+		transactionalDDL:     true,
+		upsertCount:          onConflictUpsertCount,
+		upsertBlock:          onConflictUpsertBlock,
+		migrations:           sqlMigrations,
+		insertMigration:      onConflictInsertMigration,
+		lockMigrations:       `SELECT 1 FROM pg_advisory_lock(7291452871)`,
+		unlockMigrations:     `SELECT pg_advisory_unlock(7291452871)`,
+	},
+	DialectSQLite: {
+		returning:        true,
+		transactionalDDL: true,
+		upsertCount:      onConflictUpsertCount,
+		upsertBlock:      onConflictUpsertBlock,
+		migrations:       sqlMigrations,
+		insertMigration:  onConflictInsertMigration,
+	},
+	DialectMySQL: {
+		// O MySQL avalia as atribuições da esquerda para a direita, então count é
+		// calculado com o expires_at antigo antes de expires_at ser atualizado
+		upsertCount: `INSERT INTO ratelimiter_counts (rl_key, count, expires_at) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				count = IF(expires_at <= ?, VALUES(count), count + ?),
+				expires_at = IF(expires_at <= ?, VALUES(expires_at), expires_at)`,
+		upsertBlock: `INSERT INTO ratelimiter_blocks (rl_key, blocked_until) VALUES (?, ?)
+			ON DUPLICATE KEY UPDATE blocked_until = VALUES(blocked_until)`,
+		migrations:       mysqlMigrations,
+		insertMigration:  `INSERT IGNORE INTO ratelimiter_schema_migrations (version) VALUES (?)`,
+		lockMigrations:   `SELECT GET_LOCK('ratelimiter_migrations', 60)`,
+		unlockMigrations: `SELECT RELEASE_LOCK('ratelimiter_migrations')`,
+	},
+}
+
+// SQLStore implementa RateLimiterStore sobre database/sql (PostgreSQL, MySQL ou SQLite)
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+	clock   clock.Clock
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSQLStore cria um SQLStore sobre uma conexão já aberta, aplica as migrações
+// pendentes e inicia a rotina de remoção de linhas expiradas. O SQLStore assume a
+// conexão e a fecha em Close ou, se a criação falhar, antes de retornar o erro.
+func NewSQLStore(db *sql.DB, opts SQLStoreOptions) (*SQLStore, error) {
+	dialect, ok := sqlDialects[opts.Dialect]
+	if !ok {
+		db.Close()
+		return nil, fmt.Errorf("dialeto SQL não suportado: %s", opts.Dialect)
+	}
+
+	// O SQLite permite apenas um escritor por vez; uma única conexão evita erros
+	// SQLITE_BUSY e mantém bancos :memory: consistentes entre as operações
+	if opts.Dialect == DialectSQLite {
+		db.SetMaxOpenConns(1)
+	}
+
+	s := &SQLStore{
+		db:      db,
+		dialect: dialect,
+		clock:   clock.OrDefault(opts.Clock),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("falha ao aplicar migrações: %w", err)
+	}
+
+	interval := opts.PurgeInterval
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	go s.purgeRoutine(interval)
+
+	return s, nil
+}
+
+// GetRequestCount obtém o número atual de requisições para uma chave
+func (s *SQLStore) GetRequestCount(ctx context.Context, key string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		s.rebind(`SELECT count FROM ratelimiter_counts WHERE rl_key = ? AND expires_at > ?`),
+		sqlKey(key), toMillis(s.clock.Now()),
+	).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// IncrementRequestCount incrementa o contador de requisições para uma chave
+func (s *SQLStore) IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error) {
+	return s.IncrementRequestCountBy(ctx, key, 1, expiration)
+}
+
+// IncrementRequestCountBy soma amount ao contador de requisições de uma chave
+func (s *SQLStore) IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error) {
+	count, _, err := s.IncrementRequestCountTTL(ctx, key, amount, expiration)
+	return count, err
+}
+
+// IncrementRequestCountTTL soma amount ao contador e retorna também o tempo até o fim da janela
+func (s *SQLStore) IncrementRequestCountTTL(ctx context.Context, key string, amount int, expiration time.Duration) (int, time.Duration, error) {
+	key = sqlKey(key)
+	now := s.clock.Now()
+	if amount < 0 {
+		return s.refundCount(ctx, key, -amount, now)
+	}
+	args := []any{key, amount, toMillis(now.Add(expiration)), toMillis(now), amount, toMillis(now)}
+
+	var (
+		count     int
+		expiresAt int64
+	)
+
+	// Com RETURNING o incremento e a leitura acontecem em um único comando atômico
+	if s.dialect.returning {
+		if err := s.db.QueryRowContext(ctx, s.rebind(s.dialect.upsertCount), args...).Scan(&count, &expiresAt); err != nil {
+			return 0, 0, err
+		}
+		return count, max(time.UnixMilli(expiresAt).Sub(now), 0), nil
+	}
+
+	// Sem RETURNING, a leitura é feita na mesma transação: a linha permanece
+	// travada pelo upsert até o commit
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.rebind(s.dialect.upsertCount), args...); err != nil {
+		return 0, 0, err
+	}
+
+	err = tx.QueryRowContext(ctx, s.rebind(`SELECT count, expires_at FROM ratelimiter_counts WHERE rl_key = ?`), key).Scan(&count, &expiresAt)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, max(time.UnixMilli(expiresAt).Sub(now), 0), tx.Commit()
+}
+
+// refundCount devolve amount ao contador de uma janela ativa, sem deixá-lo negativo. Sem
+// janela ativa não há o que devolver, e nenhuma linha é criada: uma linha com contador
+// negativo daria crédito à próxima janela.
+func (s *SQLStore) refundCount(ctx context.Context, key string, amount int, now time.Time) (int, time.Duration, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		s.rebind(`UPDATE ratelimiter_counts SET count = CASE WHEN count > ? THEN count - ? ELSE 0 END WHERE rl_key = ? AND expires_at > ?`),
+		amount, amount, key, toMillis(now),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		count     int
+		expiresAt int64
+	)
+	err = tx.QueryRowContext(ctx,
+		s.rebind(`SELECT count, expires_at FROM ratelimiter_counts WHERE rl_key = ? AND expires_at > ?`),
+		key, toMillis(now),
+	).Scan(&count, &expiresAt)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, time.UnixMilli(expiresAt).Sub(now), tx.Commit()
+}
+
+// GetRequestCountTTL obtém a contagem atual e o tempo até o fim da janela de uma chave
+func (s *SQLStore) GetRequestCountTTL(ctx context.Context, key string) (int, time.Duration, error) {
+	now := s.clock.Now()
+
+	var (
+		count     int
+		expiresAt int64
+	)
+	err := s.db.QueryRowContext(ctx,
+		s.rebind(`SELECT count, expires_at FROM ratelimiter_counts WHERE rl_key = ? AND expires_at > ?`),
+		sqlKey(key), toMillis(now),
+	).Scan(&count, &expiresAt)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, time.UnixMilli(expiresAt).Sub(now), nil
+}
+
+// IsBlocked verifica se uma chave está bloqueada
+func (s *SQLStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	var blocked int
+	err := s.db.QueryRowContext(ctx,
+		s.rebind(`SELECT 1 FROM ratelimiter_blocks WHERE rl_key = ? AND blocked_until > ?`),
+		sqlKey(key), toMillis(s.clock.Now()),
+	).Scan(&blocked)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar
+func (s *SQLStore) BlockTTL(ctx context.Context, key string) (time.Duration, error) {
+	var blockedUntil int64
+	err := s.db.QueryRowContext(ctx,
+		s.rebind(`SELECT blocked_until FROM ratelimiter_blocks WHERE rl_key = ?`), sqlKey(key),
+	).Scan(&blockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return max(time.UnixMilli(blockedUntil).Sub(s.clock.Now()), 0), nil
+}
+
+// Block bloqueia uma chave pelo tempo de bloqueio especificado
+func (s *SQLStore) Block(ctx context.Context, key string, blockTime time.Duration) error {
+	key = sqlKey(key)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.rebind(s.dialect.upsertBlock), key, toMillis(s.clock.Now().Add(blockTime))); err != nil {
+		return err
+	}
+
+	// Resetamos o contador quando bloqueamos
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM ratelimiter_counts WHERE rl_key = ?`), key); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close encerra a rotina de remoção e fecha a conexão com o banco
+func (s *SQLStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		err = s.db.Close()
+	})
+	return err
+}
+
+// migrate aplica, em transações individuais, as migrações ainda não registradas. Um lock
+// entre instâncias garante que duas instâncias iniciadas ao mesmo tempo não apliquem a
+// mesma migração. Sem DDL transacional (MySQL), a migração é registrada só depois que o
+// DDL termina, para que uma migração que falhou não conste como aplicada.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	// O lock pertence à sessão, então todas as migrações usam a mesma conexão
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if s.dialect.lockMigrations != "" {
+		var locked sql.NullInt64
+		if err := conn.QueryRowContext(ctx, s.dialect.lockMigrations).Scan(&locked); err != nil {
+			return fmt.Errorf("erro ao adquirir lock de migrações: %w", err)
+		}
+		if !locked.Valid || locked.Int64 != 1 {
+			return fmt.Errorf("tempo esgotado aguardando o lock de migrações")
+		}
+		// Um erro ao liberar é ignorado: o lock é liberado ao fechar a conexão
+		defer conn.ExecContext(context.Background(), s.dialect.unlockMigrations)
+	}
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS ratelimiter_schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY
+	)`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM ratelimiter_schema_migrations`).Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	for i := current; i < len(s.dialect.migrations); i++ {
+		version := i + 1
+		if !s.dialect.transactionalDDL {
+			if _, err := conn.ExecContext(ctx, s.dialect.migrations[i]); err != nil {
+				return fmt.Errorf("migração %d: %w", version, err)
+			}
+			if _, err := conn.ExecContext(ctx, s.rebind(s.dialect.insertMigration), version); err != nil {
+				return fmt.Errorf("migração %d: %w", version, err)
+			}
+			continue
+		}
+
+		err := func() error {
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+
+			// O registro vem antes do DDL: se outra instância já registrou a versão, a
+			// migração é ignorada
+			result, err := tx.ExecContext(ctx, s.rebind(s.dialect.insertMigration), version)
+			if err != nil {
+				return err
+			}
+			if n, err := result.RowsAffected(); err != nil || n == 0 {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, s.dialect.migrations[i]); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}()
+		if err != nil {
+			return fmt.Errorf("migração %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// purgeRoutine remove periodicamente as linhas expiradas
+func (s *SQLStore) purgeRoutine(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Erros são ignorados: a próxima execução tentará novamente
+			_ = s.purge(context.Background())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// purge remove contadores e bloqueios expirados
+func (s *SQLStore) purge(ctx context.Context) error {
+	now := toMillis(s.clock.Now())
+	if _, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM ratelimiter_counts WHERE expires_at <= ?`), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM ratelimiter_blocks WHERE blocked_until <= ?`), now)
+	return err
+}
+
+// rebind converte placeholders ? para o formato numerado ($1, $2...) quando o dialeto exige
+func (s *SQLStore) rebind(query string) string {
+	if !s.dialect.numberedPlaceholders {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// toMillis converte um instante para milissegundos Unix
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// sqlKey garante que a chave cabe na coluna rl_key; chaves maiores, como as formadas por
+// descritores enviados pelos clientes, são substituídas pelo seu hash
+func sqlKey(key string) string {
+	if len(key) <= sqlMaxKeyLength {
+		return key
+	}
+
+	sum := sha1.Sum([]byte(key))
+	return "h:" + hex.EncodeToString(sum[:])
+}
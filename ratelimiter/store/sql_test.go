@@ -0,0 +1,231 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestSQLStore cria um SQLStore sobre um banco SQLite temporário
+func newTestSQLStore(t *testing.T, path string) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("falha ao abrir SQLite: %v", err)
+	}
+
+	s, err := NewSQLStore(db, SQLStoreOptions{Dialect: DialectSQLite, PurgeInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("falha ao criar SQL store: %v", err)
+	}
+
+	return s
+}
+
+func TestSQLStore_CountAndBlock(t *testing.T) {
+	s := newTestSQLStore(t, filepath.Join(t.TempDir(), "ratelimiter.db"))
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Incrementos concorrentes não podem ser perdidos
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.IncrementRequestCount(ctx, "ip:1", time.Minute); err != nil {
+				t.Errorf("erro ao incrementar contador: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := s.GetRequestCount(ctx, "ip:1")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 20 {
+		t.Errorf("contagem deveria ser 20, mas recebeu %d", count)
+	}
+
+	// O bloqueio reseta o contador
+	if err := s.Block(ctx, "ip:1", time.Minute); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+	blocked, err := s.IsBlocked(ctx, "ip:1")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio: %v", err)
+	}
+	if !blocked {
+		t.Error("chave deveria estar bloqueada após Block()")
+	}
+	if count, _ := s.GetRequestCount(ctx, "ip:1"); count != 0 {
+		t.Errorf("contagem deveria ser 0 após bloqueio, mas recebeu %d", count)
+	}
+}
+
+func TestSQLStore_ExpiryAndPurge(t *testing.T) {
+	s := newTestSQLStore(t, filepath.Join(t.TempDir(), "ratelimiter.db"))
+	defer s.Close()
+
+	ctx := context.Background()
+
+	if _, err := s.IncrementRequestCount(ctx, "ip:1", 20*time.Millisecond); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if err := s.Block(ctx, "ip:2", 20*time.Millisecond); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	// Após a expiração, uma nova janela começa do zero
+	count, err := s.IncrementRequestCount(ctx, "ip:1", time.Minute)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("contagem deveria reiniciar em 1 após expiração, mas recebeu %d", count)
+	}
+	if blocked, _ := s.IsBlocked(ctx, "ip:2"); blocked {
+		t.Error("bloqueio deveria ter expirado")
+	}
+
+	// A remoção apaga apenas as linhas expiradas
+	if err := s.purge(ctx); err != nil {
+		t.Fatalf("erro na remoção de linhas expiradas: %v", err)
+	}
+	var counts, blocks int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM ratelimiter_counts`).Scan(&counts); err != nil {
+		t.Fatalf("erro ao contar contadores: %v", err)
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM ratelimiter_blocks`).Scan(&blocks); err != nil {
+		t.Fatalf("erro ao contar bloqueios: %v", err)
+	}
+	if counts != 1 || blocks != 0 {
+		t.Errorf("esperava 1 contador e 0 bloqueios após remoção, mas recebeu %d e %d", counts, blocks)
+	}
+}
+
+func TestSQLStore_MigrationsAreIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimiter.db")
+
+	// Abrir o mesmo banco duas vezes não deve reaplicar as migrações
+	newTestSQLStore(t, path).Close()
+	s := newTestSQLStore(t, path)
+	defer s.Close()
+
+	var version int
+	if err := s.db.QueryRow(`SELECT MAX(version) FROM ratelimiter_schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("erro ao ler versão do schema: %v", err)
+	}
+	if version != len(sqlMigrations) {
+		t.Errorf("versão do schema deveria ser %d, mas recebeu %d", len(sqlMigrations), version)
+	}
+}
+
+func TestSQLStore_MigrationsCanBeReapplied(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimiter.db")
+	s := newTestSQLStore(t, path)
+	defer s.Close()
+
+	// Simula um schema cujas migrações foram aplicadas sem registro (por exemplo, por uma
+	// instância que caiu antes do commit): reaplicá-las não deve falhar
+	if _, err := s.db.Exec(`DELETE FROM ratelimiter_schema_migrations WHERE version > 2`); err != nil {
+		t.Fatalf("erro ao preparar banco: %v", err)
+	}
+	if err := s.migrate(context.Background()); err != nil {
+		t.Fatalf("migrações já aplicadas deveriam ser reaplicadas sem erro: %v", err)
+	}
+
+	var applied int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM ratelimiter_schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("erro ao ler versões do schema: %v", err)
+	}
+	if applied != len(sqlMigrations) {
+		t.Errorf("deveria haver %d versões registradas, mas recebeu %d", len(sqlMigrations), applied)
+	}
+}
+
+func TestSQLStore_FailedMigrationNotRecorded(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ratelimiter.db"))
+	if err != nil {
+		t.Fatalf("falha ao abrir SQLite: %v", err)
+	}
+	defer db.Close()
+
+	// Sem DDL transacional, como no MySQL, o registro só é gravado depois do DDL
+	dialect := sqlDialects[DialectSQLite]
+	dialect.transactionalDDL = false
+	dialect.migrations = []string{sqlMigrations[0], `CREATE TABLE broken (`}
+	s := &SQLStore{db: db, dialect: dialect}
+
+	if err := s.migrate(context.Background()); err == nil {
+		t.Fatal("migração inválida deveria retornar erro")
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT MAX(version) FROM ratelimiter_schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("erro ao ler versão do schema: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("apenas a migração 1 deveria estar registrada, mas a versão é %d", version)
+	}
+}
+
+func TestSQLStore_ClosesDBOnError(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ratelimiter.db"))
+	if err != nil {
+		t.Fatalf("falha ao abrir SQLite: %v", err)
+	}
+
+	if _, err := NewSQLStore(db, SQLStoreOptions{Dialect: "oracle"}); err == nil {
+		t.Fatal("dialeto desconhecido deveria retornar erro")
+	}
+	if err := db.Ping(); err == nil {
+		t.Error("a conexão deveria ser fechada quando a criação do store falha")
+	}
+}
+
+func TestSQLStore_LongKeys(t *testing.T) {
+	s := newTestSQLStore(t, filepath.Join(t.TempDir(), "ratelimiter.db"))
+	defer s.Close()
+
+	ctx := context.Background()
+	key := "rule:export:tenant=" + strings.Repeat("x", 300)
+
+	// Chaves maiores que a coluna são gravadas pelo hash, sem perder a contagem
+	for i := 0; i < 2; i++ {
+		if _, err := s.IncrementRequestCount(ctx, key, time.Minute); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+	}
+	if count, err := s.GetRequestCount(ctx, key); err != nil || count != 2 {
+		t.Errorf("contagem deveria ser 2, mas recebeu %d (erro: %v)", count, err)
+	}
+
+	var longest int
+	if err := s.db.QueryRow(`SELECT MAX(LENGTH(rl_key)) FROM ratelimiter_counts`).Scan(&longest); err != nil {
+		t.Fatalf("erro ao ler chaves: %v", err)
+	}
+	if longest > sqlMaxKeyLength {
+		t.Errorf("chaves gravadas deveriam ter até %d caracteres, mas a maior tem %d", sqlMaxKeyLength, longest)
+	}
+}
+
+func TestSQLStore_Rebind(t *testing.T) {
+	s := &SQLStore{dialect: sqlDialects[DialectPostgres]}
+
+	got := s.rebind(`SELECT 1 FROM t WHERE a = ? AND b > ?`)
+	if want := `SELECT 1 FROM t WHERE a = $1 AND b > $2`; got != want {
+		t.Errorf("esperava %q, mas recebeu %q", want, got)
+	}
+}
@@ -13,9 +13,19 @@ type RateLimiterStore interface {
 	// IncrementRequestCount incrementa o contador de requisições para uma chave
 	IncrementRequestCount(ctx context.Context, key string, expiration time.Duration) (int, error)
 
+	// IncrementRequestCountBy soma amount ao contador de requisições de uma chave de forma
+	// atômica. Se o contador não existe (ou expirou), ele é criado com a expiração informada.
+	// Um amount negativo devolve um consumo anterior, por exemplo de uma requisição negada
+	// por um limite verificado depois: só altera um contador ativo, sem deixá-lo negativo
+	// nem alterar sua expiração, e retorna zero quando o contador não existe.
+	IncrementRequestCountBy(ctx context.Context, key string, amount int, expiration time.Duration) (int, error)
+
 	// IsBlocked verifica se uma chave está bloqueada
 	IsBlocked(ctx context.Context, key string) (bool, error)
 
+	// BlockTTL retorna quanto tempo falta para o bloqueio de uma chave terminar (0 se não está bloqueada)
+	BlockTTL(ctx context.Context, key string) (time.Duration, error)
+
 	// Block bloqueia uma chave pelo tempo de bloqueio especificado
 	Block(ctx context.Context, key string, blockTime time.Duration) error
 
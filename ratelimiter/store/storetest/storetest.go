@@ -0,0 +1,517 @@
+// Package storetest fornece um conjunto de testes de conformidade que qualquer
+// implementação de store.RateLimiterStore pode executar.
+//
+// Uso:
+//
+//	func TestMyStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) store.RateLimiterStore {
+//			return NewMyStore()
+//		}, storetest.Options{})
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pperesbr/ratelimiter/ratelimiter/store"
+)
+
+// Factory cria uma instância nova e vazia do armazenamento sob teste.
+// O suite fecha a instância ao final de cada teste.
+type Factory func(t *testing.T) store.RateLimiterStore
+
+// Options ajusta o suite às características de cada implementação
+type Options struct {
+	// Menor expiração suportada pelo armazenamento (por exemplo, 1s no Memcached).
+	// Os testes de expiração usam múltiplos desse valor. 0 usa 50ms.
+	Granularity time.Duration
+
+	// Advance avança o tempo percebido pelo armazenamento. Se nil, o suite
+	// espera em tempo real com time.Sleep.
+	Advance func(t *testing.T, s store.RateLimiterStore, d time.Duration)
+}
+
+// Run executa todos os testes de conformidade contra o armazenamento criado por newStore
+func Run(t *testing.T, newStore Factory, opts Options) {
+	if opts.Granularity <= 0 {
+		opts.Granularity = 50 * time.Millisecond
+	}
+	if opts.Advance == nil {
+		opts.Advance = func(t *testing.T, s store.RateLimiterStore, d time.Duration) {
+			time.Sleep(d)
+		}
+	}
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.RateLimiterStore, opts Options)
+	}{
+		{"Counting", testCounting},
+		{"Increment by amount", testIncrementBy},
+		{"Refund window", testRefundWindow},
+		{"Expiration", testExpiration},
+		{"Block/Unblock", testBlock},
+		{"Concurrency", testConcurrency},
+		{"Counter TTL", testCounterTTL},
+		{"Concurrency slots", testConcurrencySlots},
+		{"Token bucket", testTokenBucket},
+		{"Context cancellation", testContextCancellation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			defer s.Close()
+
+			tt.fn(t, s, opts)
+		})
+	}
+}
+
+// testCounting verifica que contadores começam em zero, são incrementados e isolados por chave
+func testCounting(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx := context.Background()
+
+	count, err := s.GetRequestCount(ctx, "ip:counting")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("contagem inicial deveria ser 0, mas recebeu %d", count)
+	}
+
+	for i := 1; i <= 3; i++ {
+		count, err := s.IncrementRequestCount(ctx, "ip:counting", time.Minute)
+		if err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+		if count != i {
+			t.Errorf("contagem após %d incrementos deveria ser %d, mas recebeu %d", i, i, count)
+		}
+	}
+
+	count, err = s.GetRequestCount(ctx, "ip:counting")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("contagem obtida deveria ser 3, mas recebeu %d", count)
+	}
+
+	// Outras chaves não são afetadas
+	count, err = s.GetRequestCount(ctx, "token:counting")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("contagem de outra chave deveria ser 0, mas recebeu %d", count)
+	}
+}
+
+// testIncrementBy verifica incrementos com quantidade, devoluções e o início de janela com a
+// quantidade informada
+func testIncrementBy(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx := context.Background()
+	window := 2 * opts.Granularity
+
+	count, err := s.IncrementRequestCountBy(ctx, "ip:amount", 5, window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("contagem inicial deveria ser 5, mas recebeu %d", count)
+	}
+
+	count, err = s.IncrementRequestCountBy(ctx, "ip:amount", 3, window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 8 {
+		t.Errorf("contagem deveria ser 8, mas recebeu %d", count)
+	}
+
+	// Uma quantidade negativa devolve um consumo anterior
+	count, err = s.IncrementRequestCountBy(ctx, "ip:amount", -3, window)
+	if err != nil {
+		t.Fatalf("erro ao devolver consumo: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("contagem após a devolução deveria ser 5, mas recebeu %d", count)
+	}
+
+	// Devolver mais do que foi contado não deixa o contador negativo
+	count, err = s.IncrementRequestCountBy(ctx, "ip:amount", -8, window)
+	if err != nil {
+		t.Fatalf("erro ao devolver consumo: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("contagem após a devolução deveria ser 0, mas recebeu %d", count)
+	}
+
+	// Após a expiração, uma devolução não tem o que devolver e a nova janela começa com a
+	// quantidade informada
+	opts.Advance(t, s, window+opts.Granularity)
+	if _, err := s.IncrementRequestCountBy(ctx, "ip:amount", -2, window); err != nil {
+		t.Fatalf("erro ao devolver consumo: %v", err)
+	}
+	count, err = s.IncrementRequestCountBy(ctx, "ip:amount", 4, window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("nova janela deveria começar em 4, mas recebeu %d", count)
+	}
+}
+
+// testRefundWindow verifica que uma devolução não prolonga a janela: o contador que volta
+// a zero e é incrementado de novo continua expirando no fim da janela original
+func testRefundWindow(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx := context.Background()
+	window := 4 * opts.Granularity
+
+	if _, err := s.IncrementRequestCountBy(ctx, "ip:refund", 2, window); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if _, err := s.IncrementRequestCountBy(ctx, "ip:refund", -2, window); err != nil {
+		t.Fatalf("erro ao devolver consumo: %v", err)
+	}
+
+	opts.Advance(t, s, 2*opts.Granularity)
+	count, err := s.IncrementRequestCountBy(ctx, "ip:refund", 2, window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("contagem deveria ser 2, mas recebeu %d", count)
+	}
+
+	opts.Advance(t, s, 3*opts.Granularity)
+	count, err = s.GetRequestCount(ctx, "ip:refund")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("a janela original deveria ter expirado, mas a contagem é %d", count)
+	}
+}
+
+// testExpiration verifica que a janela expira e um novo incremento inicia outra janela
+func testExpiration(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx := context.Background()
+	window := 2 * opts.Granularity
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.IncrementRequestCount(ctx, "ip:expiry", window); err != nil {
+			t.Fatalf("erro ao incrementar contador: %v", err)
+		}
+	}
+
+	// Incrementos dentro da janela não a prolongam
+	opts.Advance(t, s, opts.Granularity)
+	if _, err := s.IncrementRequestCount(ctx, "ip:expiry", window); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	opts.Advance(t, s, window)
+
+	count, err := s.GetRequestCount(ctx, "ip:expiry")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem após expiração: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("contagem deveria ser 0 após expiração, mas recebeu %d", count)
+	}
+
+	count, err = s.IncrementRequestCount(ctx, "ip:expiry", window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador após expiração: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("nova janela deveria começar em 1, mas recebeu %d", count)
+	}
+}
+
+// testBlock verifica bloqueio, reset do contador e expiração do bloqueio
+func testBlock(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx := context.Background()
+	blockTime := 2 * opts.Granularity
+
+	blocked, err := s.IsBlocked(ctx, "ip:block")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio: %v", err)
+	}
+	if blocked {
+		t.Error("chave não deveria estar bloqueada inicialmente")
+	}
+	if ttl, err := s.BlockTTL(ctx, "ip:block"); err != nil || ttl != 0 {
+		t.Errorf("BlockTTL de chave não bloqueada deveria ser 0, mas recebeu %v (erro: %v)", ttl, err)
+	}
+
+	if _, err := s.IncrementRequestCount(ctx, "ip:block", time.Minute); err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if err := s.Block(ctx, "ip:block", blockTime); err != nil {
+		t.Fatalf("erro ao bloquear chave: %v", err)
+	}
+
+	blocked, err = s.IsBlocked(ctx, "ip:block")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio após Block(): %v", err)
+	}
+	if !blocked {
+		t.Error("chave deveria estar bloqueada após Block()")
+	}
+
+	ttl, err := s.BlockTTL(ctx, "ip:block")
+	if err != nil {
+		t.Fatalf("erro ao obter BlockTTL: %v", err)
+	}
+	if ttl <= 0 || ttl > blockTime {
+		t.Errorf("BlockTTL deveria estar entre 0 e %v, mas recebeu %v", blockTime, ttl)
+	}
+
+	// O bloqueio reseta o contador
+	count, err := s.GetRequestCount(ctx, "ip:block")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("contagem deveria ser 0 após bloqueio, mas recebeu %d", count)
+	}
+
+	// Outras chaves não são bloqueadas
+	if blocked, _ := s.IsBlocked(ctx, "token:block"); blocked {
+		t.Error("bloqueio não deveria afetar outras chaves")
+	}
+
+	opts.Advance(t, s, blockTime+opts.Granularity)
+
+	blocked, err = s.IsBlocked(ctx, "ip:block")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio após expiração: %v", err)
+	}
+	if blocked {
+		t.Error("chave não deveria estar bloqueada após expiração")
+	}
+	if ttl, err := s.BlockTTL(ctx, "ip:block"); err != nil || ttl != 0 {
+		t.Errorf("BlockTTL após expiração deveria ser 0, mas recebeu %v (erro: %v)", ttl, err)
+	}
+}
+
+// testConcurrency verifica que incrementos concorrentes não são perdidos
+func testConcurrency(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx := context.Background()
+
+	const workers, perWorker, keys = 20, 25, 2
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				key := fmt.Sprintf("ip:concurrency:%d", (w+i)%keys)
+				if _, err := s.IncrementRequestCount(ctx, key, time.Minute); err != nil {
+					t.Errorf("erro ao incrementar contador: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	total := 0
+	for k := 0; k < keys; k++ {
+		count, err := s.GetRequestCount(ctx, fmt.Sprintf("ip:concurrency:%d", k))
+		if err != nil {
+			t.Fatalf("erro ao obter contagem: %v", err)
+		}
+		total += count
+	}
+	if total != workers*perWorker {
+		t.Errorf("total de incrementos deveria ser %d, mas recebeu %d", workers*perWorker, total)
+	}
+}
+
+// testCounterTTL verifica o tempo restante da janela informado junto com a contagem.
+// Armazenamentos que não implementam store.CounterTTLStore são ignorados.
+func testCounterTTL(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ts, ok := s.(store.CounterTTLStore)
+	if !ok {
+		t.Skip("armazenamento não implementa store.CounterTTLStore")
+	}
+	ctx := context.Background()
+	window := 4 * opts.Granularity
+
+	if count, ttl, err := ts.GetRequestCountTTL(ctx, "ip:ttl"); err != nil || count != 0 || ttl != 0 {
+		t.Errorf("chave sem janela deveria retornar 0 e 0 (contagem: %d, ttl: %v, erro: %v)", count, ttl, err)
+	}
+
+	count, ttl, err := ts.IncrementRequestCountTTL(ctx, "ip:ttl", 2, window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 2 || ttl <= 0 || ttl > window {
+		t.Errorf("nova janela deveria ter contagem 2 e TTL até %v, mas recebeu %d e %v", window, count, ttl)
+	}
+
+	// A janela começa no primeiro incremento e não é prolongada pelos seguintes
+	opts.Advance(t, s, opts.Granularity)
+	count, ttl, err = ts.IncrementRequestCountTTL(ctx, "ip:ttl", 1, window)
+	if err != nil {
+		t.Fatalf("erro ao incrementar contador: %v", err)
+	}
+	if count != 3 || ttl <= 0 || ttl > window-opts.Granularity {
+		t.Errorf("janela deveria ter contagem 3 e TTL até %v, mas recebeu %d e %v", window-opts.Granularity, count, ttl)
+	}
+
+	count, ttl, err = ts.GetRequestCountTTL(ctx, "ip:ttl")
+	if err != nil {
+		t.Fatalf("erro ao obter contagem: %v", err)
+	}
+	if count != 3 || ttl <= 0 || ttl > window-opts.Granularity {
+		t.Errorf("leitura deveria retornar contagem 3 e TTL até %v, mas recebeu %d e %v", window-opts.Granularity, count, ttl)
+	}
+}
+
+// testConcurrencySlots verifica reserva, renovação, liberação e expiração das vagas de
+// concorrência.
+// Armazenamentos que não implementam store.ConcurrencyStore são ignorados.
+func testConcurrencySlots(t *testing.T, s store.RateLimiterStore, opts Options) {
+	cs, ok := s.(store.ConcurrencyStore)
+	if !ok {
+		t.Skip("armazenamento não implementa store.ConcurrencyStore")
+	}
+	ctx := context.Background()
+	ttl := 2 * opts.Granularity
+
+	first, ok, err := cs.Acquire(ctx, "concurrency:ip:slots", 2, ttl)
+	if err != nil || !ok {
+		t.Fatalf("primeira vaga deveria ser reservada (ok: %v, erro: %v)", ok, err)
+	}
+	if _, ok, err := cs.Acquire(ctx, "concurrency:ip:slots", 2, ttl); err != nil || !ok {
+		t.Fatalf("segunda vaga deveria ser reservada (ok: %v, erro: %v)", ok, err)
+	}
+	if _, ok, err := cs.Acquire(ctx, "concurrency:ip:slots", 2, ttl); err != nil || ok {
+		t.Errorf("terceira vaga deveria ser negada (ok: %v, erro: %v)", ok, err)
+	}
+
+	// Outras chaves têm suas próprias vagas
+	if _, ok, err := cs.Acquire(ctx, "concurrency:token:slots", 1, ttl); err != nil || !ok {
+		t.Errorf("vaga de outra chave deveria ser reservada (ok: %v, erro: %v)", ok, err)
+	}
+
+	// Release devolve a vaga
+	if err := cs.Release(ctx, "concurrency:ip:slots", first); err != nil {
+		t.Fatalf("erro ao liberar vaga: %v", err)
+	}
+	if _, ok, err := cs.Acquire(ctx, "concurrency:ip:slots", 2, ttl); err != nil || !ok {
+		t.Errorf("vaga liberada deveria ser reservada novamente (ok: %v, erro: %v)", ok, err)
+	}
+
+	// Vagas não liberadas expiram após o TTL
+	opts.Advance(t, s, ttl+opts.Granularity)
+	for i := 0; i < 2; i++ {
+		if _, ok, err := cs.Acquire(ctx, "concurrency:ip:slots", 2, ttl); err != nil || !ok {
+			t.Errorf("vaga %d deveria estar livre após o TTL (ok: %v, erro: %v)", i+1, ok, err)
+		}
+	}
+
+	// Renew mantém a vaga ocupada além do TTL original
+	renewed, ok, err := cs.Acquire(ctx, "concurrency:ip:renew", 1, ttl)
+	if err != nil || !ok {
+		t.Fatalf("vaga deveria ser reservada (ok: %v, erro: %v)", ok, err)
+	}
+	opts.Advance(t, s, opts.Granularity)
+	if ok, err := cs.Renew(ctx, "concurrency:ip:renew", renewed, ttl); err != nil || !ok {
+		t.Fatalf("vaga deveria ser renovada (ok: %v, erro: %v)", ok, err)
+	}
+	opts.Advance(t, s, ttl-opts.Granularity/2)
+	if _, ok, err := cs.Acquire(ctx, "concurrency:ip:renew", 1, ttl); err != nil || ok {
+		t.Errorf("vaga renovada não deveria ter expirado (ok: %v, erro: %v)", ok, err)
+	}
+
+	// Uma reserva expirada não pode ser renovada
+	opts.Advance(t, s, ttl+opts.Granularity)
+	if ok, err := cs.Renew(ctx, "concurrency:ip:renew", renewed, ttl); err != nil || ok {
+		t.Errorf("vaga expirada não deveria ser renovada (ok: %v, erro: %v)", ok, err)
+	}
+}
+
+// testTokenBucket verifica o consumo, a espera e o reabastecimento dos baldes de fichas.
+// Armazenamentos que não implementam store.TokenBucketStore são ignorados.
+func testTokenBucket(t *testing.T, s store.RateLimiterStore, opts Options) {
+	tb, ok := s.(store.TokenBucketStore)
+	if !ok {
+		t.Skip("armazenamento não implementa store.TokenBucketStore")
+	}
+	ctx := context.Background()
+
+	// Balde de 1000 fichas reabastecido a 1000 fichas por granularidade
+	interval := opts.Granularity
+	rate := 1000 / interval.Seconds()
+
+	// O balde começa cheio
+	wait, err := tb.Reserve(ctx, "bandwidth:ip:bucket", 600, rate, 1000)
+	if err != nil {
+		t.Fatalf("erro ao reservar fichas: %v", err)
+	}
+	if wait != 0 {
+		t.Errorf("reserva dentro do saldo não deveria esperar, mas recebeu %v", wait)
+	}
+
+	// O saldo fica negativo: a espera cobre as fichas que faltam
+	wait, err = tb.Reserve(ctx, "bandwidth:ip:bucket", 900, rate, 1000)
+	if err != nil {
+		t.Fatalf("erro ao reservar fichas: %v", err)
+	}
+	if want := interval / 2; wait < want-opts.Granularity/10 || wait > want+opts.Granularity/10 {
+		t.Errorf("espera deveria ser cerca de %v, mas recebeu %v", want, wait)
+	}
+
+	// Outras chaves têm seu próprio balde
+	if wait, err := tb.Reserve(ctx, "bandwidth:token:bucket", 1000, rate, 1000); err != nil || wait != 0 {
+		t.Errorf("balde de outra chave deveria estar cheio (espera: %v, erro: %v)", wait, err)
+	}
+
+	// O balde é reabastecido com o tempo, até o limite
+	opts.Advance(t, s, 3*interval)
+	if wait, err := tb.Reserve(ctx, "bandwidth:ip:bucket", 1000, rate, 1000); err != nil || wait != 0 {
+		t.Errorf("balde reabastecido não deveria esperar (espera: %v, erro: %v)", wait, err)
+	}
+	if wait, err := tb.Reserve(ctx, "bandwidth:ip:bucket", 1, rate, 1000); err != nil || wait == 0 {
+		t.Errorf("balde não deveria passar do limite de 1000 fichas (espera: %v, erro: %v)", wait, err)
+	}
+}
+
+// testContextCancellation verifica que operações com contexto cancelado retornam erro
+func testContextCancellation(t *testing.T, s store.RateLimiterStore, opts Options) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checks := map[string]error{}
+	_, checks["GetRequestCount"] = s.GetRequestCount(ctx, "ip:canceled")
+	_, checks["IncrementRequestCount"] = s.IncrementRequestCount(ctx, "ip:canceled", time.Minute)
+	_, checks["IncrementRequestCountBy"] = s.IncrementRequestCountBy(ctx, "ip:canceled", 2, time.Minute)
+	_, checks["IsBlocked"] = s.IsBlocked(ctx, "ip:canceled")
+	_, checks["BlockTTL"] = s.BlockTTL(ctx, "ip:canceled")
+	checks["Block"] = s.Block(ctx, "ip:canceled", time.Minute)
+
+	for op, err := range checks {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s com contexto cancelado deveria retornar context.Canceled, mas recebeu %v", op, err)
+		}
+	}
+
+	// Nenhuma alteração deve ter sido aplicada
+	blocked, err := s.IsBlocked(context.Background(), "ip:canceled")
+	if err != nil {
+		t.Fatalf("erro ao verificar bloqueio: %v", err)
+	}
+	if blocked {
+		t.Error("Block com contexto cancelado não deveria bloquear a chave")
+	}
+}
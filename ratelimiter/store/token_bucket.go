@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// TokenBucketStore é implementado pelos armazenamentos que suportam baldes de fichas,
+// usados pelo limite de banda. É uma interface opcional: verifique com uma type assertion.
+type TokenBucketStore interface {
+	// Reserve consome n fichas do balde da chave, que é reabastecido a rate fichas por
+	// segundo até burst fichas. O saldo pode ficar negativo: o retorno é quanto tempo o
+	// chamador deve esperar até que as fichas reservadas estejam disponíveis (0 se já estão).
+	Reserve(ctx context.Context, key string, n int, rate float64, burst int) (time.Duration, error)
+}
+
+// tokenBucket é o estado de um balde de fichas em memória
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time
+}
+
+// reserve reabastece o balde até now e consome n fichas, retornando a espera necessária
+func (b *tokenBucket) reserve(now time.Time, n int, rate float64, burst int) time.Duration {
+	if b.updated.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = min(float64(burst), b.tokens+elapsed.Seconds()*rate)
+	}
+	b.updated = now
+	b.tokens -= float64(n)
+
+	// Instante em que o balde volta a ficar cheio e pode ser descartado
+	b.fullAt = now.Add(time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second)))
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}